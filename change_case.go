@@ -3,277 +3,300 @@ package parser
 import (
 	"strings"
 	"unicode"
-	"unsafe"
+	"unicode/utf8"
 )
 
+// Deprecated: use CamelCase.
 func CamelCaseOptimized(input string) string {
-	var result strings.Builder
-	result.Grow(len(input))
-
-	capitalizeNext := false
-	for i, r := range input {
-		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
-			capitalizeNext = true
-			continue
-		}
+	return CamelCase(input)
+}
 
-		if capitalizeNext || i == 0 {
-			result.WriteRune(unicode.ToUpper(r))
-			capitalizeNext = false
-		} else {
-			result.WriteRune(unicode.ToLower(r))
-		}
-	}
+// Deprecated: use CamelCase. CamelCaseUnsafe never writes to or aliases the
+// memory of input; the result owns a freshly allocated buffer.
+func CamelCaseUnsafe(input string) string {
+	return CamelCase(input)
+}
 
-	res := []rune(result.String())
-	res[0] = unicode.ToLower(res[0])
-	return string(res)
+// Deprecated: use CamelCase.
+func CamelCaseUnsafe2(input string) string {
+	return CamelCase(input)
 }
 
-func StringToBytes(s string) []byte {
-	return unsafe.Slice(unsafe.StringData(s), len(s))
+// Deprecated: use CamelCase.
+func CamelCaseUnsafe3(input string) string {
+	return CamelCase(input)
 }
 
-func BytesToString(b []byte) string {
-	return unsafe.String(&b[0], len(b))
+// Deprecated: use Words.
+func CamelCaseUnsafeTokens(input string) []Token {
+	return Words(input)
 }
 
-func CamelCaseUnsafe(input string) string {
+type WordCase int
+
+const (
+	LowerWord WordCase = iota // word
+	UpperWord                 // WORD
+	TitleWord                 // Word
+)
 
-	inBytes := *(*[]byte)(unsafe.Pointer(&input))
+type CaseStyle struct {
+	Separator string
+	First     WordCase
+	Rest      WordCase
+}
 
-	outBytes := unsafe.Slice(unsafe.StringData(input), 0)[:]
+var (
+	CamelStyle          = CaseStyle{Separator: "", First: LowerWord, Rest: TitleWord}  // camelCase
+	PascalStyle         = CaseStyle{Separator: "", First: TitleWord, Rest: TitleWord}  // PascalCase
+	SnakeStyle          = CaseStyle{Separator: "_", First: LowerWord, Rest: LowerWord} // snake_case
+	ScreamingSnakeStyle = CaseStyle{Separator: "_", First: UpperWord, Rest: UpperWord} // SCREAMING_SNAKE_CASE
+	KebabStyle          = CaseStyle{Separator: "-", First: LowerWord, Rest: LowerWord} // kebab-case
+	TrainStyle          = CaseStyle{Separator: "-", First: TitleWord, Rest: TitleWord} // Train-Case
+	DotStyle            = CaseStyle{Separator: ".", First: LowerWord, Rest: LowerWord} // dot.case
+	PathStyle           = CaseStyle{Separator: "/", First: LowerWord, Rest: LowerWord} // path/case
+	TitleStyle          = CaseStyle{Separator: " ", First: TitleWord, Rest: TitleWord} // Title Case
+	SentenceStyle       = CaseStyle{Separator: " ", First: TitleWord, Rest: LowerWord} // Sentence case
+	FlatStyle           = CaseStyle{Separator: "", First: LowerWord, Rest: LowerWord}  // flatcase
+)
 
-	capitalizeNext := true
-	for _, b := range inBytes {
-		if b == '_' || b == '-' || b == ' ' || unicode.IsPunct(rune(b)) {
-			capitalizeNext = true
-			continue
-		}
-		if capitalizeNext {
-			if b >= 'a' && b <= 'z' {
-				b -= 'a' - 'A'
-			}
-			capitalizeNext = false
-		} else {
-			if b >= 'A' && b <= 'Z' {
-				b += 'a' - 'A'
-			}
-		}
-		outBytes = append(outBytes, b)
-	}
+type CaseOptions struct {
+	PreserveAcronyms bool // Initialisms and all-caps words stay upper case: userID, HTTPServer
+	PreserveDigits   bool // A word starting with a digit keeps a "_" before it: user_2fa
 
-	return *(*string)(unsafe.Pointer(&outBytes))
-	return ""
+	// Language is a BCP 47 tag such as "tr", "de-DE" or "el" selecting special
+	// casing: Turkish dotted and dotless i, German ß -> SS, Greek final sigma.
+	Language string
+
+	// Transliterate, when set, is applied before splitting words so that the
+	// result is ASCII: Привет мир -> privet_mir with DefaultTranslit.
+	Transliterate TranslitTable
 }
 
-func CamelCaseUnsafe2(input string) string {
-	// Получаем указатель на исходные байты строки
-	inBytes := unsafe.Slice(unsafe.StringData(input), len(input))
+// Reversible guarantees that snake_case -> any style -> snake_case returns the
+// original for well-formed identifiers: lowercase words of two or more letters
+// or digits, none of which is itself a concatenation of Initialisms.
+var Reversible = CaseOptions{PreserveAcronyms: true, PreserveDigits: true}
+
+func ConvertCase(s string, style CaseStyle) string {
+	return ConvertCaseWith(s, style, CaseOptions{})
+}
 
-	// Создаем новый срез для результата
-	outBytes := make([]byte, 0, len(input))
+func ConvertCaseWith(s string, style CaseStyle, opts CaseOptions) string {
 
-	capitalizeNext := true
-	for _, b := range inBytes {
-		if b == '_' || b == '-' || b == ' ' || unicode.IsPunct(rune(b)) {
-			capitalizeNext = true
-			continue
-		}
+	return BytesToString(AppendCase(make([]byte, 0, len(s)), s, style, opts))
+}
 
-		if capitalizeNext {
-			// Преобразуем в заглавный символ, если это буква
-			if b >= 'a' && b <= 'z' {
-				b -= 'a' - 'A'
-			}
-			capitalizeNext = false
-		} else {
-			// Преобразуем в строчный символ, если это буква
-			if b >= 'A' && b <= 'Z' {
-				b += 'a' - 'A'
-			}
-		}
+// AppendCase appends s converted to style to dst and returns the extended
+// buffer. It does not allocate when dst has enough capacity, unless
+// opts.Transliterate is set; pure ASCII input takes a byte-wise fast path,
+// anything else is converted rune by rune.
+func AppendCase(dst []byte, s string, style CaseStyle, opts CaseOptions) []byte {
 
-		// Добавляем байт в результат
-		outBytes = append(outBytes, b)
+	if opts.Transliterate != nil {
+		s = Transliterate(s, opts.Transliterate)
 	}
 
-	// Преобразуем результат обратно в строку через unsafe
-	return *(*string)(unsafe.Pointer(&outBytes))
-}
+	w := newWordScanner(s)
 
-func CamelCaseUnsafe3(input string) string {
-	// Получаем указатель на исходные байты строки
-	inBytes := unsafe.Slice(unsafe.StringData(input), len(input))
+	lang := parseCaseLanguage(opts.Language)
 
-	// Выделяем память под результат с использованием unsafe
-	outBytes := make([]byte, len(input))
-	outPtr := unsafe.Pointer(&outBytes[0])
+	start, end, ok := w.next()
 
-	// Инициализируем счетчик для записи в новый срез
-	writeIndex := 0
-	capitalizeNext := true
+	for i := 0; ok; i++ {
 
-	for _, b := range inBytes {
-		if b == '_' || b == '-' || b == ' ' || unicode.IsPunct(rune(b)) {
-			capitalizeNext = true
-			continue
-		}
+		word := s[start:end]
+
+		nextStart, nextEnd, nextOk := w.next()
 
-		if capitalizeNext {
-			// Преобразуем в заглавный символ, если это буква
-			if b >= 'a' && b <= 'z' {
-				b -= 'a' - 'A'
+		wordCase := style.Rest
+		{
+			if i == 0 {
+				wordCase = style.First
+			} else if opts.PreserveDigits && style.Separator == "" && startsWithDigit(word) {
+				dst = append(dst, '_')
+			} else {
+				dst = append(dst, style.Separator...)
 			}
-			capitalizeNext = false
-		} else {
-			// Преобразуем в строчный символ, если это буква
-			if b >= 'A' && b <= 'Z' {
-				b += 'a' - 'A'
+		}
+
+		if opts.PreserveAcronyms && wordCase == TitleWord && isAcronym(word) {
+			if !nextOk || !letterDigit(s[nextStart:nextEnd]) {
+				wordCase = UpperWord
 			}
 		}
 
-		// Записываем байт в выделенную память
-		*(*byte)(unsafe.Add(outPtr, writeIndex)) = b
-		writeIndex++
+		dst = appendWord(dst, word, wordCase, w.ascii, lang)
+
+		start, end, ok = nextStart, nextEnd, nextOk
 	}
 
-	// Преобразуем результат обратно в строку
-	return *(*string)(unsafe.Pointer(&outBytes))
+	return dst
 }
 
-func CamelCaseUnsafeTokens(input string) []Token {
-	// Получаем указатель на исходные байты строки
-	// inBytes := unsafe.Slice(unsafe.SliceData(input), len(input))
+func appendWord(dst []byte, word string, wordCase WordCase, ascii bool, lang caseLanguage) []byte {
 
-	// Создаем новый срез для результата
-	// outBytes := make([]byte, 0, len(input))
+	if ascii && lang.asciiSafe() {
+		for i := 0; i < len(word); i++ {
+			c := word[i]
+			if wordCase == UpperWord || wordCase == TitleWord && i == 0 {
+				if 'a' <= c && c <= 'z' {
+					c -= 'a' - 'A'
+				}
+			} else if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			dst = append(dst, c)
+		}
+		return dst
+	}
 
-	inRunes := []rune(input)
+	for i, r := range word {
+		if wordCase == UpperWord || wordCase == TitleWord && i == 0 {
+			dst = lang.appendUpper(dst, r, wordCase == TitleWord)
+		} else {
+			dst = lang.appendLower(dst, r, i+utf8.RuneLen(r) == len(word) && i > 0)
+		}
+	}
 
-	var result []Token
+	return dst
+}
 
-	capitalizeNext := true
-	for _, b := range inRunes {
+func isAcronym(word string) bool {
 
-		if b == '_' || b == '-' || b == ' ' || unicode.IsPunct(rune(b)) {
-			capitalizeNext = true
-			continue
+	if isInitialism(word) {
+		return true
+	}
+
+	n := 0
+	for _, r := range word {
+		if !unicode.IsUpper(r) {
+			return false
 		}
+		n++
+	}
 
-		// if capitalizeNext {
-		// 	// Преобразуем в заглавный символ, если это буква
-		// 	if b >= 'a' && b <= 'z' {
-		// 		b -= 'a' - 'A'
-		// 	}
-		// 	capitalizeNext = false
-		// } else {
-		// 	// Преобразуем в строчный символ, если это буква
-		// 	if b >= 'A' && b <= 'Z' {
-		// 		b += 'a' - 'A'
-		// 	}
-		// }
-
-		if capitalizeNext {
-			if b >= 'a' && b <= 'z' {
-				// b -= 'a' - 'A'
-			}
-			capitalizeNext = false
+	return n > 1
+}
 
-		} else {
-			if b >= 'A' && b <= 'Z' {
-				// b += 'a' - 'A'
-			}
-		}
+func isInitialism(word string) bool {
+
+	var buf [16]byte
+	if len(word) > len(buf) {
+		return Initialisms[strings.ToUpper(word)]
+	}
 
-		// result = append(result, Token{
-		// 	Type:  IDENT,
-		// 	Value: string(b),
-		// 	Start: 0,
-		// 	End:   0,
-		// })
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c >= utf8.RuneSelf {
+			return false
+		}
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		buf[i] = c
 	}
 
-	// Преобразуем результат обратно в строку через unsafe
-	return result
+	return Initialisms[string(buf[:len(word)])]
 }
 
-func CamelCase(s string) string {
-	p := NewParser(s)
+func startsWithDigit(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsDigit(r)
+}
 
-	var result = make([]byte, 0, len(s))
+// letterDigit reports whether word starts like "v2", which an upper case
+// acronym in front of it would swallow: HTTPV2.
+func letterDigit(word string) bool {
+	r, size := utf8.DecodeRuneInString(word)
+	next, _ := utf8.DecodeRuneInString(word[size:])
+	return unicode.IsLetter(r) && unicode.IsDigit(next)
+}
 
-	// tokens := Filter(p.Parse(), func(t Token) bool {
-	// 	return t.Type == IDENT
-	// })
+func AppendCamel(dst []byte, s string) []byte {
+	return AppendCase(dst, s, CamelStyle, CaseOptions{})
+}
 
-	for i, token := range p.Parse() {
-		if token.Type == IDENT {
-			res := token.Join(token.SplitUpper, func(s string) string {
-				if len(s) == 0 {
-					return s
-				}
+func AppendPascal(dst []byte, s string) []byte {
+	return AppendCase(dst, s, PascalStyle, CaseOptions{})
+}
 
-				if i == 0 {
-					q := []rune(s)
-					if unicode.IsLower(q[0]) {
-						return s
-					}
-					q[0] = unicode.ToLower(q[0])
-					return string(q)
-				}
+func AppendSnake(dst []byte, s string) []byte {
+	return AppendCase(dst, s, SnakeStyle, CaseOptions{})
+}
 
-				q := []rune(s)
+func AppendScreamingSnake(dst []byte, s string) []byte {
+	return AppendCase(dst, s, ScreamingSnakeStyle, CaseOptions{})
+}
 
-				if unicode.IsUpper(q[0]) {
-					return s
-				}
+func AppendKebab(dst []byte, s string) []byte {
+	return AppendCase(dst, s, KebabStyle, CaseOptions{})
+}
 
-				q[0] = unicode.ToUpper(q[0])
-				return string(q)
-			})
+func AppendTrain(dst []byte, s string) []byte {
+	return AppendCase(dst, s, TrainStyle, CaseOptions{})
+}
 
-			result = append(result, res...)
-		}
-	}
+func AppendDot(dst []byte, s string) []byte {
+	return AppendCase(dst, s, DotStyle, CaseOptions{})
+}
 
-	return string(result)
+func AppendPath(dst []byte, s string) []byte {
+	return AppendCase(dst, s, PathStyle, CaseOptions{})
 }
 
-// func PascalCase(s string) string {
-// 	p := NewParser(s)
+func AppendTitle(dst []byte, s string) []byte {
+	return AppendCase(dst, s, TitleStyle, CaseOptions{})
+}
+
+func AppendSentence(dst []byte, s string) []byte {
+	return AppendCase(dst, s, SentenceStyle, CaseOptions{})
+}
 
-// 	tokens := p.Parse()
+func AppendFlat(dst []byte, s string) []byte {
+	return AppendCase(dst, s, FlatStyle, CaseOptions{})
+}
 
-// 	for _, token := range tokens {
-// 		token.UpperFirst()
-// 	}
+func CamelCase(s string) string {
+	return ConvertCase(s, CamelStyle)
+}
 
-// 	return strings.Join(tokens, "")
-// }
+func PascalCase(s string) string {
+	return ConvertCase(s, PascalStyle)
+}
 
 func SnakeCase(s string) string {
-	p := NewParser(s)
+	return ConvertCase(s, SnakeStyle)
+}
 
-	tokens := Filter(p.Parse(), func(t Token) bool {
-		return t.Type == IDENT
-	})
+func ScreamingSnakeCase(s string) string {
+	return ConvertCase(s, ScreamingSnakeStyle)
+}
 
-	var result []byte
+func KebabCase(s string) string {
+	return ConvertCase(s, KebabStyle)
+}
 
-	for i, token := range tokens {
-		if token.Type == IDENT {
+func TrainCase(s string) string {
+	return ConvertCase(s, TrainStyle)
+}
 
-			res := token.Join(token.SplitUpper, func(s string) string {
-				if i == 0 {
-					return strings.ToLower(s)
-				}
-				return "_" + strings.ToLower(s)
-			})
+func DotCase(s string) string {
+	return ConvertCase(s, DotStyle)
+}
 
-			result = append(result, res...)
-		}
-	}
+func PathCase(s string) string {
+	return ConvertCase(s, PathStyle)
+}
+
+func TitleCase(s string) string {
+	return ConvertCase(s, TitleStyle)
+}
+
+func SentenceCase(s string) string {
+	return ConvertCase(s, SentenceStyle)
+}
 
-	return string(result)
+func FlatCase(s string) string {
+	return ConvertCase(s, FlatStyle)
 }
@@ -0,0 +1,354 @@
+// Package combinator builds small recursive-descent parsers out of functions
+// over the tokens of a parser.Lexer.
+package combinator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fobus1289/parser"
+)
+
+// Stream is a token stream with backtracking and recovered errors.
+type Stream struct {
+	tokens []parser.Token
+	pos    int
+	errors []error
+
+	lexErrors []error
+}
+
+// NewStream returns a Stream over tokens, which should end with EOF as
+// returned by parser.Parser.Parse.
+func NewStream(tokens []parser.Token) *Stream {
+	if len(tokens) == 0 || tokens[len(tokens)-1].Type != parser.EOF {
+		end := 0
+		if len(tokens) > 0 {
+			end = tokens[len(tokens)-1].End
+		}
+		tokens = append(tokens[:len(tokens):len(tokens)], parser.Token{Type: parser.EOF, Value: "EOF", Start: end, End: end})
+	}
+	return &Stream{tokens: tokens}
+}
+
+// NewStreamFromString lexes input with config; the lexer's errors are kept in
+// LexErrors.
+func NewStreamFromString(input string, config parser.LexerConfig) *Stream {
+	p := parser.NewParserWithConfig(input, config)
+	s := NewStream(p.Parse())
+	s.lexErrors = p.Errors()
+	return s
+}
+
+func (s *Stream) LexErrors() []error {
+	return s.lexErrors
+}
+
+// Peek returns the next token, or EOF at the end.
+func (s *Stream) Peek() parser.Token {
+	return s.tokens[s.pos]
+}
+
+func (s *Stream) Next() parser.Token {
+	token := s.tokens[s.pos]
+	if s.pos < len(s.tokens)-1 {
+		s.pos++
+	}
+	return token
+}
+
+// Checkpoint is a saved Stream position, see Mark and Reset.
+type Checkpoint struct {
+	pos    int
+	errors int
+}
+
+func (s *Stream) Mark() Checkpoint {
+	return Checkpoint{pos: s.pos, errors: len(s.errors)}
+}
+
+// Reset returns to checkpoint and forgets the errors recovered since.
+func (s *Stream) Reset(checkpoint Checkpoint) {
+	s.pos = max(0, min(checkpoint.pos, len(s.tokens)-1))
+	s.errors = s.errors[:min(checkpoint.errors, len(s.errors))]
+}
+
+// Errors returns the errors skipped over by Recover.
+func (s *Stream) Errors() []error {
+	return s.errors
+}
+
+// Error is a parse failure at the rune offset Pos, where Found was seen
+// instead of any of Expected.
+type Error struct {
+	Pos      int
+	Expected []string
+	Found    parser.Token
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("combinator: expected %s, found %s %q at %d", strings.Join(e.Expected, " or "), e.Found.Type, e.Found.Value, e.Pos)
+}
+
+func (s *Stream) errorf(expected ...string) error {
+	found := s.Peek()
+	return &Error{Pos: found.Start, Expected: expected, Found: found}
+}
+
+// Parser parses a T from the stream. On failure it returns an error and the
+// combinators restore the stream to where the parser started.
+type Parser[T any] func(s *Stream) (T, error)
+
+// Type matches one token of type tokenType.
+func Type(tokenType parser.TokenType) Parser[parser.Token] {
+	return func(s *Stream) (parser.Token, error) {
+		if s.Peek().Type != tokenType {
+			return parser.Token{}, s.errorf(tokenType.String())
+		}
+		return s.Next(), nil
+	}
+}
+
+// Value matches one token of type tokenType whose Value is value.
+func Value(tokenType parser.TokenType, value string) Parser[parser.Token] {
+	return func(s *Stream) (parser.Token, error) {
+		if token := s.Peek(); token.Type != tokenType || token.Value != value {
+			return parser.Token{}, s.errorf(fmt.Sprintf("%q", value))
+		}
+		return s.Next(), nil
+	}
+}
+
+// EOF matches the end of the stream.
+func EOF() Parser[parser.Token] {
+	return Type(parser.EOF)
+}
+
+// Seq runs ps in order and collects their results.
+func Seq[T any](ps ...Parser[T]) Parser[[]T] {
+	return func(s *Stream) ([]T, error) {
+		mark := s.Mark()
+		values := make([]T, 0, len(ps))
+		for _, p := range ps {
+			value, err := p(s)
+			if err != nil {
+				s.Reset(mark)
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+}
+
+// Alt returns the result of the first of ps to succeed. When all fail, the
+// error is the one that got furthest, listing what every alternative failing
+// there expected.
+func Alt[T any](ps ...Parser[T]) Parser[T] {
+	return func(s *Stream) (T, error) {
+		mark := s.Mark()
+
+		var furthest *Error
+		var last error
+
+		for _, p := range ps {
+			value, err := p(s)
+			if err == nil {
+				return value, nil
+			}
+			s.Reset(mark)
+
+			last = err
+
+			var pe *Error
+			if !errors.As(err, &pe) {
+				continue
+			}
+
+			switch {
+			case furthest == nil || pe.Pos > furthest.Pos:
+				furthest = &Error{Pos: pe.Pos, Expected: append([]string(nil), pe.Expected...), Found: pe.Found}
+			case pe.Pos == furthest.Pos:
+				for _, expected := range pe.Expected {
+					if !contains(furthest.Expected, expected) {
+						furthest.Expected = append(furthest.Expected, expected)
+					}
+				}
+			}
+		}
+
+		var zero T
+		if furthest != nil {
+			return zero, furthest
+		}
+		return zero, last
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Many runs p until it fails or stops consuming tokens.
+func Many[T any](p Parser[T]) Parser[[]T] {
+	return func(s *Stream) ([]T, error) {
+		var values []T
+		for {
+			mark := s.Mark()
+			value, err := p(s)
+			if err != nil {
+				s.Reset(mark)
+				return values, nil
+			}
+			values = append(values, value)
+			if s.pos == mark.pos {
+				return values, nil
+			}
+		}
+	}
+}
+
+// Optional returns the zero value of T instead of failing.
+func Optional[T any](p Parser[T]) Parser[T] {
+	return func(s *Stream) (T, error) {
+		mark := s.Mark()
+		value, err := p(s)
+		if err != nil {
+			s.Reset(mark)
+			var zero T
+			return zero, nil
+		}
+		return value, nil
+	}
+}
+
+// SepBy matches zero or more p separated by sep. Every sep must be followed
+// by a p.
+func SepBy[T, S any](p Parser[T], sep Parser[S]) Parser[[]T] {
+	return func(s *Stream) ([]T, error) {
+		mark := s.Mark()
+
+		first, err := p(s)
+		if err != nil {
+			s.Reset(mark)
+			return nil, nil
+		}
+
+		values := []T{first}
+
+		for {
+			if _, err := sep(s); err != nil {
+				return values, nil
+			}
+			value, err := p(s)
+			if err != nil {
+				s.Reset(mark)
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+}
+
+// Between matches open, p and close and returns the result of p.
+func Between[O, T, C any](open Parser[O], p Parser[T], close Parser[C]) Parser[T] {
+	return func(s *Stream) (T, error) {
+		mark := s.Mark()
+		var zero T
+		if _, err := open(s); err != nil {
+			return zero, err
+		}
+		value, err := p(s)
+		if err != nil {
+			s.Reset(mark)
+			return zero, err
+		}
+		if _, err := close(s); err != nil {
+			s.Reset(mark)
+			return zero, err
+		}
+		return value, nil
+	}
+}
+
+func Map[T, U any](p Parser[T], fn func(T) U) Parser[U] {
+	return func(s *Stream) (U, error) {
+		value, err := p(s)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(value), nil
+	}
+}
+
+// Lazy defers building a parser until it runs, for recursive grammars.
+func Lazy[T any](fn func() Parser[T]) Parser[T] {
+	return func(s *Stream) (T, error) {
+		return fn()(s)
+	}
+}
+
+// Recover runs p and, on failure, records the error in Stream.Errors, skips
+// tokens up to one of sync (which is left in the stream) or EOF, and returns
+// the zero value of T.
+func Recover[T any](p Parser[T], sync ...parser.TokenType) Parser[T] {
+	return func(s *Stream) (T, error) {
+		mark := s.Mark()
+		value, err := p(s)
+		if err == nil {
+			return value, nil
+		}
+
+		s.Reset(mark)
+		s.errors = append(s.errors, err)
+
+		for {
+			tokenType := s.Peek().Type
+			if tokenType == parser.EOF || containsType(sync, tokenType) {
+				break
+			}
+			s.Next()
+		}
+
+		var zero T
+		return zero, nil
+	}
+}
+
+func containsType(types []parser.TokenType, tokenType parser.TokenType) bool {
+	for _, t := range types {
+		if t == tokenType {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse lexes input with config and runs p, which must consume everything up
+// to EOF. Lexer errors and errors recorded by Recover are joined into the
+// returned error.
+func Parse[T any](p Parser[T], input string, config parser.LexerConfig) (T, error) {
+
+	s := NewStreamFromString(input, config)
+
+	value, err := p(s)
+	if err == nil {
+		_, err = EOF()(s)
+	}
+
+	errs := append(append(append([]error(nil), s.lexErrors...), s.errors...), err)
+
+	if err = errors.Join(errs...); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,169 @@
+package combinator
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/fobus1289/parser"
+)
+
+var config = parser.LexerConfig{
+	Ident:     parser.IdentGo,
+	Strings:   true,
+	Numbers:   true,
+	Operators: parser.DefaultOperators,
+	Space:     parser.DropSpace,
+}
+
+type condition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+func conditions() Parser[[]condition] {
+
+	op := Alt(Type(parser.EQ), Type(parser.NEQ), Type(parser.LTE), Type(parser.GTE), Type(parser.LT), Type(parser.GT))
+	value := Alt(Type(parser.STRING), Type(parser.NUMBER), Type(parser.IDENT))
+
+	cond := Map(Seq(Type(parser.IDENT), op, value), func(tokens []parser.Token) condition {
+		return condition{Field: tokens[0].Value, Op: tokens[1].Value, Value: tokens[2].Value}
+	})
+
+	return SepBy(cond, Type(parser.AND))
+}
+
+func TestConditions(t *testing.T) {
+
+	result, err := Parse(conditions(), `name == "bob" && age >= 3 && role != admin`, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []condition{{"name", "==", "bob"}, {"age", ">=", "3"}, {"role", "!=", "admin"}}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, result)
+	}
+
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], result[i])
+		}
+	}
+
+	_, err = Parse(conditions(), `name == "bob" && age 3`, config)
+
+	var pe *Error
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+
+	if pe.Pos != 21 || pe.Found.Type != parser.NUMBER || len(pe.Expected) != 6 {
+		t.Errorf("Expected 6 operators at 21, got %v", pe)
+	}
+}
+
+type list struct {
+	Items []any
+}
+
+func TestNestedLists(t *testing.T) {
+
+	var value Parser[any]
+
+	number := Map(Type(parser.NUMBER), func(token parser.Token) any {
+		n, _ := token.Int()
+		return n
+	})
+
+	items := Between(Type(parser.LBRACKET), SepBy(Lazy(func() Parser[any] { return value }), Type(parser.COMMA)), Type(parser.RBRACKET))
+
+	value = Alt(number, Map(items, func(items []any) any { return list{items} }))
+
+	result, err := Parse(value, "[1, [2, 3], [], 4]", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outer := result.(list)
+	if len(outer.Items) != 4 || outer.Items[0] != int64(1) || len(outer.Items[1].(list).Items) != 2 || len(outer.Items[2].(list).Items) != 0 {
+		t.Errorf("Unexpected result %#v", result)
+	}
+
+	if _, err := Parse(value, "[1, [2, 3]", config); err == nil {
+		t.Errorf("Expected error for unclosed list")
+	}
+
+	if _, err := Parse(value, "[1,]", config); err == nil {
+		t.Errorf("Expected error for trailing comma")
+	}
+}
+
+func TestRecover(t *testing.T) {
+
+	assignment := Map(Seq(Type(parser.IDENT), Type(parser.ASSIGN), Type(parser.NUMBER)), func(tokens []parser.Token) string {
+		return tokens[0].Value + "=" + tokens[2].Value
+	})
+
+	statement := Map(Seq(Recover(assignment, parser.SEMICOLON), Map(Type(parser.SEMICOLON), func(parser.Token) string { return "" })), func(values []string) string {
+		return values[0]
+	})
+
+	s := NewStreamFromString("a = 1; b = ; c = 3;", config)
+
+	result, err := Many(statement)(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 3 || result[0] != "a=1" || result[1] != "" || result[2] != "c=3" {
+		t.Errorf("Unexpected result %q", result)
+	}
+
+	if len(s.Errors()) != 1 || s.Errors()[0].(*Error).Pos != 11 {
+		t.Errorf("Expected one error at 11, got %v", s.Errors())
+	}
+
+	if _, err := Parse(Many(statement), "a = 1; b = ;", config); err == nil {
+		t.Errorf("Expected recovered errors to be returned by Parse")
+	}
+}
+
+func TestStream(t *testing.T) {
+
+	s := NewStream(nil)
+	if s.Next().Type != parser.EOF || s.Next().Type != parser.EOF {
+		t.Errorf("Expected EOF forever")
+	}
+
+	s = NewStreamFromString("a b", config)
+	mark := s.Mark()
+	s.Next()
+	if s.Peek().Value != "b" {
+		t.Errorf("Expected b, got %v", s.Peek())
+	}
+
+	s.Reset(mark)
+	if v, _ := Optional(Value(parser.IDENT, "x"))(s); v.Value != "" || s.Peek().Value != "a" {
+		t.Errorf("Expected Optional to leave the stream untouched")
+	}
+
+	if n, err := Map(Many(Type(parser.IDENT)), func(tokens []parser.Token) string { return strconv.Itoa(len(tokens)) })(s); err != nil || n != "2" {
+		t.Errorf("Expected 2 identifiers, got %s", n)
+	}
+}
+
+func TestParse_LexErrors(t *testing.T) {
+
+	_, err := Parse(Type(parser.STRING), `"a\qb"`, config)
+
+	var se *parser.SyntaxError
+	if !errors.As(err, &se) {
+		t.Errorf("Expected lexer SyntaxError, got %v", err)
+	}
+
+	if s := NewStreamFromString(`"ok"`, config); len(s.LexErrors()) != 0 {
+		t.Errorf("Expected no lexer errors, got %v", s.LexErrors())
+	}
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"regexp"
+	"sync"
+	"unicode"
+)
+
+type KeyConvention struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Convert func(string) string
+}
+
+var (
+	UpperSnakeKeys = KeyConvention{
+		Name:    "UPPER_SNAKE",
+		Pattern: regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`),
+		Convert: ScreamingSnakeCase,
+	}
+	CamelKeys = KeyConvention{
+		Name:    "camelCase",
+		Pattern: regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+		Convert: CamelCase,
+	}
+	KebabKeys = KeyConvention{
+		Name:    "kebab-case",
+		Pattern: regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`),
+		Convert: KebabCase,
+	}
+)
+
+func (c KeyConvention) Match(key string) bool {
+	return c.Pattern.MatchString(key)
+}
+
+type KeyViolation struct {
+	Key        string
+	Suggestion string
+	Start      int
+	End        int
+}
+
+func ValidateKeys(input string, convention KeyConvention) []KeyViolation {
+
+	var violations []KeyViolation
+
+	for _, token := range NewParser(input).ParsePlaceholders() {
+
+		if token.Type == COMMENT {
+			continue
+		}
+
+		key := ParsePlaceholderToken(token).Key
+
+		if convention.Match(key) {
+			continue
+		}
+
+		violations = append(violations, KeyViolation{
+			Key:        key,
+			Suggestion: convention.Convert(key),
+			Start:      token.Start,
+			End:        token.End,
+		})
+	}
+
+	return violations
+}
+
+// FixKeys rewrites only the key of each violating placeholder, so types,
+// defaults, filters and the optional marker are kept.
+func FixKeys(input string, convention KeyConvention) (string, []KeyViolation) {
+
+	violations := ValidateKeys(input, convention)
+	if len(violations) == 0 {
+		return input, nil
+	}
+
+	runes := []rune(input)
+
+	var prevEnd int
+
+	buff := make([]rune, 0, len(runes))
+
+	for _, v := range violations {
+		if v.Suggestion == "" {
+			continue
+		}
+		start := v.Start + 1
+		for start < v.End && unicode.IsSpace(runes[start]) {
+			start++
+		}
+		buff = append(buff, runes[prevEnd:start]...)
+		buff = append(buff, []rune(v.Suggestion)...)
+		prevEnd = start + len([]rune(v.Key))
+	}
+
+	buff = append(buff, runes[prevEnd:]...)
+
+	return string(buff), violations
+}
+
+// keyCacheSize bounds a keyCache; when full it starts over.
+const keyCacheSize = 1024
+
+type keyCache struct {
+	mu        sync.RWMutex
+	keys      map[string]string
+	normalize func(string) string
+}
+
+func newKeyCache(normalize func(string) string) *keyCache {
+	return &keyCache{keys: make(map[string]string), normalize: normalize}
+}
+
+func (c *keyCache) get(key string) string {
+	c.mu.RLock()
+	normalized, ok := c.keys[key]
+	c.mu.RUnlock()
+
+	if ok {
+		return normalized
+	}
+
+	normalized = c.normalize(key)
+
+	c.mu.Lock()
+	if len(c.keys) >= keyCacheSize {
+		c.keys = make(map[string]string)
+	}
+	c.keys[key] = normalized
+	c.mu.Unlock()
+
+	return normalized
+}
+
+// NormalizeKeys wraps replacer so that placeholder keys and map keys are both
+// passed through normalize before lookup, e.g. NormalizeKeys(data, SnakeCase)
+// resolves {user_id}, {userId} and {user-id} to the same entry.
+//
+// A placeholder key present in the map as is always finds its own entry. When
+// several map keys normalize alike, the first of them in sorted order wins.
+func NormalizeKeys[T Replacer](replacer T, normalize func(string) string) func(string) string {
+
+	cache := newKeyCache(normalize)
+
+	switch v := any(replacer).(type) {
+	case map[string]string:
+		normalized := make(map[string]string, len(v))
+		for _, key := range sortedKeys(v) {
+			if n := normalize(key); !hasKey(normalized, n) {
+				normalized[n] = v[key]
+			}
+		}
+		return func(key string) string {
+			if value, ok := v[key]; ok {
+				return value
+			}
+			return normalized[cache.get(key)]
+		}
+	case func(string) string:
+		return func(key string) string {
+			return v(cache.get(key))
+		}
+	}
+
+	return func(string) string { return "" }
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
@@ -1,14 +1,238 @@
 package parser
 
-import "unicode"
+import (
+	"strings"
+	"unicode"
+)
+
+type IdentMode int
+
+const (
+	IdentLetters IdentMode = iota // Letters only: user_id2 -> IDENT UNDERSCORE IDENT NUMBER
+	IdentGo                       // Go: letter or _, then letters, digits and _
+	IdentUnicode                  // UAX #31: XID_Start, then XID_Continue
+)
+
+type LexerConfig struct {
+	Ident   IdentMode
+	Space   SpaceMode
+	Strings bool // "...", '...' and `...` literals as single STRING tokens
+	Numbers bool // 3.14, -5, 1e9, 0xFF, 0o7, 0b1, 1_000 as single NUMBER tokens
+
+	Comments CommentStyle
+
+	// Keywords turns matching identifiers into other token types, e.g.
+	// DefaultKeywords; FoldKeywords makes the match case-insensitive.
+	Keywords     map[string]TokenType
+	FoldKeywords bool
+
+	// Operators are matched longest first, so with DefaultOperators "<=="
+	// lexes as LTE ASSIGN. Nil means BarOperators: only || is joined, into
+	// BAR, and every other punctuation rune is a token of its own.
+	Operators map[string]TokenType
+
+	// Punctuation is merged over DefaultPunctuation, so callers can add runes
+	// or remap existing ones; a rune mapped to INVALID is lexed as an INVALID
+	// token, like any rune missing from the table.
+	Punctuation map[rune]TokenType
+
+	Rules []Rule
+}
+
+type SpaceMode int
+
+const (
+	KeepSpace     SpaceMode = iota // One SPACE token per whitespace rune
+	CoalesceSpace                  // One SPACE per run of spaces and tabs, one NEWLINE per run of line breaks
+	DropSpace                      // No SPACE or NEWLINE tokens
+	TriviaSpace                    // Whitespace goes into Leading and Trailing of the neighbouring tokens
+)
+
+type CommentStyle int
+
+const (
+	SlashComments CommentStyle = 1 << iota // // to end of line
+	HashComments                           // # to end of line
+	BlockComments                          // /* ... */
+)
+
+var DefaultKeywords = map[string]TokenType{
+	"true":  BOOL,
+	"false": BOOL,
+	"null":  NULL,
+	"nil":   NULL,
+}
+
+var DefaultPunctuation = map[rune]TokenType{
+	'{':  LBRACE,
+	'}':  RBRACE,
+	'(':  LPAREN,
+	')':  RPAREN,
+	'[':  LBRACKET,
+	']':  RBRACKET,
+	'<':  LT,
+	'>':  GT,
+	'=':  ASSIGN,
+	':':  COLON,
+	',':  COMMA,
+	';':  SEMICOLON,
+	'|':  PIPE,
+	'"':  QUOTE,
+	'\'': CHAR,
+	'-':  MINUS,
+	'+':  PLUS,
+	'*':  ASTERISK,
+	'/':  SLASH,
+	'%':  PERCENT,
+	'^':  CARET,
+	'&':  AMPERSAND,
+	'_':  UNDERSCORE,
+	'@':  AT,
+	'!':  EXCLAMATION,
+	'?':  QUESTION,
+	'.':  PERIOD,
+	'$':  DOLLAR,
+	'#':  HASH,
+	'~':  TILDE,
+	'`':  BACKTICK,
+}
+
+// BarOperators are the operators of a Lexer whose config sets none.
+var BarOperators = map[string]TokenType{
+	"||": BAR,
+}
+
+var DefaultOperators = map[string]TokenType{
+	"==": EQ,
+	"!=": NEQ,
+	"<=": LTE,
+	">=": GTE,
+	"&&": AND,
+	"||": BAR,
+	"->": ARROW,
+	"::": DCOLON,
+}
 
 type Lexer struct {
-	input []rune
-	pos   int
+	input       []rune
+	pos         int
+	config      LexerConfig
+	keywords    map[string]TokenType
+	punctuation map[rune]TokenType
+	operators   map[string]TokenType
+	operatorLen int // Longest key of operators in runes
+	rules       []Rule
 }
 
 func NewLexer(input string) *Lexer {
-	return &Lexer{input: []rune(input), pos: 0}
+	return NewLexerWithConfig(input, LexerConfig{})
+}
+
+func NewLexerWithConfig(input string, config LexerConfig) *Lexer {
+
+	keywords := config.Keywords
+	{
+		if config.FoldKeywords {
+			keywords = make(map[string]TokenType, len(config.Keywords))
+			for word, tokenType := range config.Keywords {
+				keywords[strings.ToLower(word)] = tokenType
+			}
+		}
+	}
+
+	punctuation := DefaultPunctuation
+	{
+		if config.Punctuation != nil {
+			punctuation = make(map[rune]TokenType, len(DefaultPunctuation)+len(config.Punctuation))
+			for r, tokenType := range DefaultPunctuation {
+				punctuation[r] = tokenType
+			}
+			for r, tokenType := range config.Punctuation {
+				punctuation[r] = tokenType
+			}
+		}
+	}
+
+	operators := config.Operators
+	{
+		if operators == nil {
+			operators = BarOperators
+		}
+	}
+
+	operatorLen := 0
+	{
+		for operator := range operators {
+			if n := len([]rune(operator)); n > operatorLen {
+				operatorLen = n
+			}
+		}
+	}
+
+	l := &Lexer{input: []rune(input), pos: 0, config: config, keywords: keywords, punctuation: punctuation, operators: operators, operatorLen: operatorLen}
+
+	for _, rule := range config.Rules {
+		l.AddRule(rule)
+	}
+
+	return l
+}
+
+func (l *Lexer) isPunctuation(r rune) bool {
+	_, ok := l.punctuation[r]
+	return ok
+}
+
+// operator returns the longest of the operators starting at start.
+func (l *Lexer) operator(start int) (TokenType, int, bool) {
+
+	for n := min(l.operatorLen, len(l.input)-start); n > 0; n-- {
+		if tokenType, ok := l.operators[string(l.input[start:start+n])]; ok {
+			return tokenType, n, true
+		}
+	}
+
+	return INVALID, 0, false
+}
+
+func (l *Lexer) keyword(ident string) (TokenType, bool) {
+
+	if l.keywords == nil {
+		return IDENT, false
+	}
+
+	if l.config.FoldKeywords {
+		ident = strings.ToLower(ident)
+	}
+
+	tokenType, ok := l.keywords[ident]
+
+	return tokenType, ok
+}
+
+func (l *Lexer) Config() LexerConfig {
+	return l.config
+}
+
+func (l *Lexer) isIdentStart(r rune) bool {
+	switch l.config.Ident {
+	case IdentGo:
+		return r == '_' || unicode.IsLetter(r)
+	case IdentUnicode:
+		return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start)
+	}
+	return unicode.IsLetter(r)
+}
+
+func (l *Lexer) isIdentContinue(r rune) bool {
+	switch l.config.Ident {
+	case IdentGo:
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	case IdentUnicode:
+		return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start,
+			unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+	}
+	return unicode.IsLetter(r)
 }
 
 func (l *Lexer) Next() (rune, bool) {
@@ -27,20 +251,62 @@ func (l *Lexer) Peek() (rune, bool) {
 	return l.input[l.pos], true
 }
 
+// PeekN returns the rune n positions after the current one; PeekN(0) is Peek
+// and PeekN(-1) the rune before.
+func (l *Lexer) PeekN(n int) (rune, bool) {
+	if i := l.pos + n; i < 0 || i >= len(l.input) {
+		return 0, false
+	}
+	return l.input[l.pos+n], true
+}
+
+// Checkpoint is a saved Lexer position, see Mark and Reset.
+type Checkpoint struct {
+	pos int
+}
+
+func (l *Lexer) Mark() Checkpoint {
+	return Checkpoint{pos: l.pos}
+}
+
+func (l *Lexer) Reset(checkpoint Checkpoint) {
+	l.seek(checkpoint.pos)
+}
+
+// Pos returns the current rune offset into the input.
+func (l *Lexer) Pos() int {
+	return l.pos
+}
+
+// Len returns the length of the input in runes.
+func (l *Lexer) Len() int {
+	return len(l.input)
+}
+
+func (l *Lexer) Remaining() int {
+	return len(l.input) - l.pos
+}
+
+// seek moves to pos, clamped to the input, so positions never go negative or
+// past the end.
+func (l *Lexer) seek(pos int) {
+	l.pos = max(0, min(pos, len(l.input)))
+}
+
 func (l *Lexer) NextPos() {
-	l.pos++
+	l.seek(l.pos + 1)
 }
 
 func (l *Lexer) NextPosN(n int) {
-	l.pos += n
+	l.seek(l.pos + n)
 }
 
 func (l *Lexer) PrevPos() {
-	l.pos--
+	l.seek(l.pos - 1)
 }
 
 func (l *Lexer) PrevPosN(n int) {
-	l.pos -= n
+	l.seek(l.pos - n)
 }
 
 func (l *Lexer) EscapeSpace() {
@@ -95,3 +361,36 @@ func (l *Lexer) Read(open, close rune, tokenType TokenType) (Token, bool) {
 
 	return token, true
 }
+
+func (l *Lexer) HasPrefix(prefix string) bool {
+
+	i := l.pos
+	for _, r := range prefix {
+		if i >= len(l.input) || l.input[i] != r {
+			return false
+		}
+		i++
+	}
+
+	return true
+}
+
+// ReadDelimited reads from the current position, which must be at open, up
+// to and including close. When close is missing it reads to the end and
+// returns false.
+func (l *Lexer) ReadDelimited(open, close string, tokenType TokenType) (Token, bool) {
+
+	start := l.pos
+
+	l.NextPosN(len([]rune(open)))
+
+	for !l.HasPrefix(close) {
+		if _, ok := l.Next(); !ok {
+			return Token{Type: tokenType, Value: string(l.input[start:l.pos]), Start: start, End: l.pos}, false
+		}
+	}
+
+	l.NextPosN(len([]rune(close)))
+
+	return Token{Type: tokenType, Value: string(l.input[start:l.pos]), Start: start, End: l.pos}, true
+}
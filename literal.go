@@ -0,0 +1,372 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+type SyntaxError struct {
+	Msg   string
+	Start int
+	End   int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s [%d:%d]", e.Msg, e.Start, e.End)
+}
+
+func (p *Parser) errorf(start, end int, format string, args ...any) {
+	p.errors = append(p.errors, &SyntaxError{Msg: fmt.Sprintf(format, args...), Start: start, End: end})
+}
+
+// parseString lexes a literal opened by quote at start. Double and single
+// quoted literals decode escapes and end at the line; backtick literals are
+// raw and may span lines. Value holds the decoded text, Raw the source.
+func (p *Parser) parseString(quote rune, start int) Token {
+
+	l := p.lexer
+
+	var value strings.Builder
+
+	for {
+		r, ok := l.Next()
+		if !ok || (r == '\n' && quote != '`') {
+			if ok {
+				l.PrevPos()
+			}
+			p.errorf(start, l.pos, "unterminated string literal")
+			raw := string(l.input[start:l.pos])
+			return Token{Type: INVALID, Value: raw, Raw: raw, Start: start, End: l.pos}
+		}
+
+		if r == quote {
+			break
+		}
+
+		if r == '\\' && quote != '`' {
+			p.parseEscape(&value, quote)
+			continue
+		}
+
+		value.WriteRune(r)
+	}
+
+	return Token{Type: STRING, Value: value.String(), Raw: string(l.input[start:l.pos]), Start: start, End: l.pos}
+}
+
+var simpleEscapes = map[rune]rune{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '/': '/', '"': '"', '\'': '\'', '`': '`',
+}
+
+func (p *Parser) parseEscape(value *strings.Builder, quote rune) {
+
+	l := p.lexer
+
+	start := l.pos - 1
+
+	r, ok := l.Peek()
+	if !ok || r == '\n' {
+		p.errorf(start, l.pos, "unterminated escape sequence")
+		return
+	}
+
+	l.NextPos()
+
+	if esc, ok := simpleEscapes[r]; ok {
+		value.WriteRune(esc)
+		return
+	}
+
+	var digits, base int
+	{
+		switch {
+		case r == 'x':
+			digits, base = 2, 16
+		case r == 'u':
+			digits, base = 4, 16
+		case r == 'U':
+			digits, base = 8, 16
+		case '0' <= r && r <= '7':
+			digits, base = 3, 8
+			l.PrevPos()
+		default:
+			p.errorf(start, l.pos, "unknown escape sequence \\%c", r)
+			value.WriteRune(r)
+			return
+		}
+	}
+
+	code, ok := p.readDigits(digits, base)
+	if !ok {
+		p.errorf(start, l.pos, "invalid escape sequence %s", string(l.input[start:l.pos]))
+		return
+	}
+
+	if r == 'x' || base == 8 {
+		if code > 0xFF {
+			p.errorf(start, l.pos, "octal escape value %d > 255", code)
+			return
+		}
+		value.WriteByte(byte(code))
+		return
+	}
+
+	if utf16.IsSurrogate(rune(code)) && r == 'u' && code < 0xDC00 {
+		if low, ok := p.lowSurrogate(); ok {
+			value.WriteRune(utf16.DecodeRune(rune(code), low))
+			return
+		}
+	}
+
+	// Like strconv.Unquote, reject unpaired surrogate halves and values past
+	// unicode.MaxRune instead of decoding them to U+FFFD.
+	if code > unicode.MaxRune || utf16.IsSurrogate(rune(code)) {
+		p.errorf(start, l.pos, "escape sequence %s is an invalid Unicode code point", string(l.input[start:l.pos]))
+		return
+	}
+
+	value.WriteRune(rune(code))
+}
+
+func (p *Parser) lowSurrogate() (rune, bool) {
+
+	l := p.lexer
+
+	mark := l.pos
+
+	if r, ok := l.Next(); !ok || r != '\\' {
+		l.pos = mark
+		return 0, false
+	}
+
+	if r, ok := l.Next(); !ok || r != 'u' {
+		l.pos = mark
+		return 0, false
+	}
+
+	code, ok := p.readDigits(4, 16)
+	if !ok || code < 0xDC00 || code > 0xDFFF {
+		l.pos = mark
+		return 0, false
+	}
+
+	return rune(code), true
+}
+
+func (p *Parser) readDigits(n, base int) (int, bool) {
+
+	code := 0
+
+	for i := 0; i < n; i++ {
+		r, ok := p.lexer.Peek()
+		if !ok {
+			return 0, false
+		}
+
+		d := digitValue(r)
+		if d < 0 || d >= base {
+			return 0, false
+		}
+
+		code = code*base + d
+		p.lexer.NextPos()
+	}
+
+	return code, true
+}
+
+func digitValue(r rune) int {
+	switch {
+	case '0' <= r && r <= '9':
+		return int(r - '0')
+	case 'a' <= r && r <= 'z':
+		return int(r-'a') + 10
+	case 'A' <= r && r <= 'Z':
+		return int(r-'A') + 10
+	}
+	return -1
+}
+
+type NumberKind int
+
+const (
+	NotNumber    NumberKind = iota // Not a NUMBER token
+	NumberInt                      // 42, -5, 1_000
+	NumberFloat                    // 3.14, 1e9, .5
+	NumberHex                      // 0xFF
+	NumberOctal                    // 0o755, 0755
+	NumberBinary                   // 0b1010
+)
+
+var numberKindNames = [...]string{
+	NotNumber:    "none",
+	NumberInt:    "int",
+	NumberFloat:  "float",
+	NumberHex:    "hex",
+	NumberOctal:  "octal",
+	NumberBinary: "binary",
+}
+
+// String returns the name of k, or NumberKind(n) when k is unknown.
+func (k NumberKind) String() string {
+	if k >= 0 && int(k) < len(numberKindNames) {
+		return numberKindNames[k]
+	}
+	return fmt.Sprintf("NumberKind(%d)", int(k))
+}
+
+// afterValue reports whether a sign or '.' here would follow a value directly:
+// "-5", "(-5" and "a -5" start numbers, "a-5" and "items.1" do not.
+func afterValue(tokens []Token) bool {
+
+	if len(tokens) == 0 {
+		return false
+	}
+
+	switch tokens[len(tokens)-1].Type {
+	case IDENT, NUMBER, STRING, RPAREN, RBRACE:
+		return true
+	}
+
+	return false
+}
+
+func isDigitOf(r rune, base int) bool {
+	d := digitValue(r)
+	return d >= 0 && d < base
+}
+
+// parseNumber lexes a number whose first rune (a digit, '.', or a sign) has
+// already been consumed at start.
+func (p *Parser) parseNumber(start int) Token {
+
+	l := p.lexer
+
+	digits := start
+
+	first := l.input[start]
+	if first == '-' || first == '+' {
+		first, _ = l.Next()
+		digits++
+	}
+
+	kind := NumberInt
+
+	if first == '0' {
+		r, _ := l.Peek()
+		base, prefixKind := 0, NotNumber
+		switch r {
+		case 'x', 'X':
+			base, prefixKind = 16, NumberHex
+		case 'o', 'O':
+			base, prefixKind = 8, NumberOctal
+		case 'b', 'B':
+			base, prefixKind = 2, NumberBinary
+		}
+
+		if base > 0 {
+			if next, ok := l.PeekN(1); ok && (isDigitOf(next, base) || next == '_') {
+				l.NextPosN(2)
+				p.readNumberDigits(base)
+				return p.numberToken(start, prefixKind)
+			}
+		}
+	}
+
+	if first == '.' {
+		kind = NumberFloat
+	}
+
+	p.readNumberDigits(10)
+
+	if r, _ := l.Peek(); r == '.' && kind == NumberInt {
+		if next, ok := l.PeekN(1); ok && isDigitOf(next, 10) {
+			kind = NumberFloat
+			l.NextPos()
+			p.readNumberDigits(10)
+		}
+	}
+
+	if r, _ := l.Peek(); r == 'e' || r == 'E' {
+		n := 1
+		if sign, _ := l.PeekN(1); sign == '-' || sign == '+' {
+			n = 2
+		}
+		if next, ok := l.PeekN(n); ok && isDigitOf(next, 10) {
+			kind = NumberFloat
+			l.NextPosN(n)
+			p.readNumberDigits(10)
+		}
+	}
+
+	// A leading zero makes an octal literal, as in Go: 0755. 089 is an
+	// error rather than a silent decimal.
+	if kind == NumberInt && first == '0' && l.pos-digits > 1 {
+		kind = NumberOctal
+		for i, r := range l.input[digits+1 : l.pos] {
+			if r != '_' && !isDigitOf(r, 8) {
+				at := digits + 1 + i
+				p.errorf(at, at+1, "invalid digit %q in octal literal", r)
+				kind = NumberInt
+				break
+			}
+		}
+	}
+
+	return p.numberToken(start, kind)
+}
+
+// readNumberDigits consumes digits of base, allowing single underscores
+// between them.
+func (p *Parser) readNumberDigits(base int) {
+
+	l := p.lexer
+
+	for {
+		r, ok := l.Peek()
+		if !ok {
+			return
+		}
+
+		if r == '_' {
+			if next, ok := l.PeekN(1); ok && isDigitOf(next, base) {
+				l.NextPos()
+				continue
+			}
+			return
+		}
+
+		if !isDigitOf(r, base) {
+			return
+		}
+
+		l.NextPos()
+	}
+}
+
+func (p *Parser) numberToken(start int, kind NumberKind) Token {
+	l := p.lexer
+	return Token{Type: NUMBER, Kind: kind, Value: string(l.input[start:l.pos]), Start: start, End: l.pos}
+}
+
+func (t Token) Int() (int64, error) {
+	if t.Type != NUMBER || t.Kind == NumberFloat {
+		return 0, fmt.Errorf("parser: %s %q is not an integer", t.Type, t.Value)
+	}
+	return strconv.ParseInt(t.Value, 0, 64)
+}
+
+func (t Token) Float() (float64, error) {
+	if t.Type != NUMBER {
+		return 0, fmt.Errorf("parser: %s %q is not a number", t.Type, t.Value)
+	}
+	if t.Kind != NumberFloat {
+		i, err := t.Int()
+		return float64(i), err
+	}
+	return strconv.ParseFloat(t.Value, 64)
+}
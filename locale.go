@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type caseLanguage int
+
+const (
+	anyLanguage     caseLanguage = iota // Plain Unicode case mapping
+	turkishLanguage                     // tr, az: i <-> İ, ı <-> I
+	germanLanguage                      // de: ß -> SS
+	greekLanguage                       // el: final σ -> ς, no accents in upper case
+)
+
+func parseCaseLanguage(tag string) caseLanguage {
+
+	base := tag
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+
+	switch strings.ToLower(base) {
+	case "tr", "az":
+		return turkishLanguage
+	case "de":
+		return germanLanguage
+	case "el":
+		return greekLanguage
+	}
+
+	return anyLanguage
+}
+
+// asciiSafe reports whether ASCII letters map the usual way in lang.
+func (lang caseLanguage) asciiSafe() bool {
+	return lang != turkishLanguage
+}
+
+var greekUpperAccents = map[rune]rune{
+	'ά': 'Α', 'έ': 'Ε', 'ή': 'Η', 'ί': 'Ι', 'ό': 'Ο', 'ύ': 'Υ', 'ώ': 'Ω',
+	'Ά': 'Α', 'Έ': 'Ε', 'Ή': 'Η', 'Ί': 'Ι', 'Ό': 'Ο', 'Ύ': 'Υ', 'Ώ': 'Ω',
+	'ΐ': 'Ϊ', 'ΰ': 'Ϋ',
+}
+
+func (lang caseLanguage) appendUpper(dst []byte, r rune, title bool) []byte {
+
+	switch lang {
+	case turkishLanguage:
+		r = unicode.TurkishCase.ToUpper(r)
+	case germanLanguage:
+		if r == 'ß' {
+			if title {
+				return append(dst, "Ss"...)
+			}
+			return append(dst, "SS"...)
+		}
+		r = unicode.ToUpper(r)
+	case greekLanguage:
+		if upper, ok := greekUpperAccents[r]; ok && !title {
+			r = upper
+		} else {
+			r = unicode.ToUpper(r)
+		}
+	default:
+		r = unicode.ToUpper(r)
+	}
+
+	return utf8.AppendRune(dst, r)
+}
+
+// appendLower appends r lower cased; final is set when r ends its word.
+func (lang caseLanguage) appendLower(dst []byte, r rune, final bool) []byte {
+
+	switch lang {
+	case turkishLanguage:
+		r = unicode.TurkishCase.ToLower(r)
+	case germanLanguage:
+		if r == 'ẞ' {
+			r = 'ß'
+		} else {
+			r = unicode.ToLower(r)
+		}
+	case greekLanguage:
+		if r == 'Σ' && final {
+			r = 'ς'
+		} else {
+			r = unicode.ToLower(r)
+		}
+	default:
+		r = unicode.ToLower(r)
+	}
+
+	return utf8.AppendRune(dst, r)
+}
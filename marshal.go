@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func (t TokenType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText accepts the names returned by String, including TokenType(n).
+func (t *TokenType) UnmarshalText(text []byte) error {
+
+	name := string(text)
+
+	if tokenType, ok := ParseTokenType(name); ok {
+		*t = tokenType
+		return nil
+	}
+
+	if n, ok := strings.CutPrefix(name, "TokenType("); ok {
+		if n, ok := strings.CutSuffix(n, ")"); ok {
+			if i, err := strconv.Atoi(n); err == nil {
+				*t = TokenType(i)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("parser: unknown token type %q", name)
+}
+
+func (k NumberKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *NumberKind) UnmarshalText(text []byte) error {
+
+	for kind, name := range numberKindNames {
+		if name == string(text) {
+			*k = NumberKind(kind)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("parser: unknown number kind %q", text)
+}
+
+// tokenJSON is the JSON shape of a Token:
+//
+//	{"type":"NUMBER","value":"0xFF","kind":"hex","start":4,"end":8}
+type tokenJSON struct {
+	Type   TokenType  `json:"type"`
+	Value  string     `json:"value"`
+	Raw    string     `json:"raw,omitempty"`
+	Kind   NumberKind `json:"kind,omitempty"`
+	Start  int        `json:"start"`
+	End    int        `json:"end"`
+	IsLast bool       `json:"last,omitempty"`
+
+	Leading  string `json:"leading,omitempty"`
+	Trailing string `json:"trailing,omitempty"`
+}
+
+func (t Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenJSON(t))
+}
+
+func (t *Token) UnmarshalJSON(data []byte) error {
+
+	var v tokenJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*t = Token(v)
+
+	return nil
+}
+
+type DumpFormat int
+
+const (
+	DumpJSON      DumpFormat = iota // One indented JSON array
+	DumpJSONLines                   // One JSON object per line
+	DumpTable                       // Aligned TYPE, VALUE, START and END columns
+)
+
+func DumpTokens(w io.Writer, tokens []Token, format DumpFormat) error {
+
+	switch format {
+	case DumpJSON:
+		if tokens == nil {
+			tokens = []Token{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tokens)
+	case DumpJSONLines:
+		enc := json.NewEncoder(w)
+		for _, token := range tokens {
+			if err := enc.Encode(token); err != nil {
+				return err
+			}
+		}
+		return nil
+	case DumpTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TYPE\tVALUE\tSTART\tEND")
+		for _, token := range tokens {
+			fmt.Fprintf(tw, "%s\t%q\t%d\t%d\n", token.Type, token.Value, token.Start, token.End)
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("parser: unknown dump format %d", format)
+}
@@ -7,17 +7,26 @@ import (
 )
 
 type Parser struct {
-	lexer *Lexer
+	lexer  *Lexer
+	errors []error
 }
 
 func NewParser(input string) *Parser {
 	return NewParserWithLexer(NewLexer(input))
 }
 
+func NewParserWithConfig(input string, config LexerConfig) *Parser {
+	return NewParserWithLexer(NewLexerWithConfig(input, config))
+}
+
 func NewParserWithLexer(lexer *Lexer) *Parser {
 	return &Parser{lexer: lexer}
 }
 
+func (p *Parser) Errors() []error {
+	return p.errors
+}
+
 func (p *Parser) Parse() []Token {
 
 	l := p.lexer
@@ -34,22 +43,47 @@ func (p *Parser) Parse() []Token {
 			break
 		}
 
+		if len(l.rules) > 0 {
+			if token, ok := l.rule(start); ok {
+				tokens = append(tokens, token)
+				continue
+			}
+		}
+
 		var token Token
 
 		switch {
+		case unicode.IsSpace(symbol) && l.config.Space != KeepSpace:
+			tokens = append(tokens, p.parseSpace(symbol, start))
+			continue
 		case unicode.IsSpace(symbol):
 			token = Token{Type: SPACE, Value: string(symbol), Start: start, End: l.pos}
-		case unicode.IsLetter(symbol):
+		case l.config.Comments != 0 && p.isCommentStart(symbol):
+			tokens = append(tokens, p.parseComment(start))
+			continue
+		case l.config.Strings && (symbol == '"' || symbol == '\'' || symbol == '`'):
+			tokens = append(tokens, p.parseString(symbol, start))
+			continue
+		case l.config.Numbers && p.isNumberStart(symbol, tokens):
+			tokens = append(tokens, p.parseNumber(start))
+			continue
+		case l.operatorLen > 0 && p.isOperatorStart(start):
+			tokens = append(tokens, p.parseOperator(start))
+			continue
+		case l.isIdentStart(symbol):
 
 			for {
-				symbol, _ := l.Peek()
-				if unicode.IsSpace(symbol) || !unicode.IsLetter(symbol) {
+				symbol, ok := l.Peek()
+				if !ok || !l.isIdentContinue(symbol) {
 					break
 				}
 				l.NextPos()
 			}
 
 			token = Token{Type: IDENT, Value: string(p.lexer.input[start:l.pos]), Start: start, End: l.pos}
+			if tokenType, ok := l.keyword(token.Value); ok {
+				token.Type = tokenType
+			}
 			tokens = append(tokens, token)
 			continue
 		case unicode.IsDigit(symbol):
@@ -62,57 +96,11 @@ func (p *Parser) Parse() []Token {
 				l.NextPos()
 			}
 
-			token = Token{Type: NUMBER, Value: string(p.lexer.input[start:l.pos]), Start: start, End: l.pos}
+			token = Token{Type: NUMBER, Kind: NumberInt, Value: string(p.lexer.input[start:l.pos]), Start: start, End: l.pos}
 			tokens = append(tokens, token)
 			continue
-		case symbol == '{':
-			token = Token{Type: LBRACE, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '}':
-			token = Token{Type: RBRACE, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '(':
-			token = Token{Type: LPAREN, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == ')':
-			token = Token{Type: RPAREN, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '=':
-			token = Token{Type: ASSIGN, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == ':':
-			token = Token{Type: COLON, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == ',':
-			token = Token{Type: COMMA, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == ';':
-			token = Token{Type: SEMICOLON, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '|':
-			token = Token{Type: PIPE, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '"':
-			token = Token{Type: QUOTE, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '\'':
-			token = Token{Type: CHAR, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '-':
-			token = Token{Type: MINUS, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '+':
-			token = Token{Type: PLUS, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '*':
-			token = Token{Type: ASTERISK, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '/':
-			token = Token{Type: SLASH, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '%':
-			token = Token{Type: PERCENT, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '^':
-			token = Token{Type: CARET, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '&':
-			token = Token{Type: AMPERSAND, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '|':
-			token = Token{Type: BAR, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '_':
-			token = Token{Type: UNDERSCORE, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '@':
-			token = Token{Type: AT, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '!':
-			token = Token{Type: EXCLAMATION, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '.':
-			token = Token{Type: PERIOD, Value: string(symbol), Start: start, End: l.pos}
-		case symbol == '?':
-			token = Token{Type: QUESTION, Value: string(symbol), Start: start, End: l.pos}
+		case l.isPunctuation(symbol):
+			token = Token{Type: l.punctuation[symbol], Value: string(symbol), Start: start, End: l.pos}
 		default:
 			token = Token{Type: INVALID, Value: string(symbol), Start: start, End: l.pos}
 		}
@@ -120,7 +108,81 @@ func (p *Parser) Parse() []Token {
 		tokens = append(tokens, token)
 	}
 
-	return tokens
+	return applySpace(tokens, l.config.Space)
+}
+
+func (p *Parser) isCommentStart(symbol rune) bool {
+
+	next, _ := p.lexer.Peek()
+
+	switch {
+	case symbol == '/' && next == '/':
+		return p.lexer.config.Comments&SlashComments != 0
+	case symbol == '/' && next == '*':
+		return p.lexer.config.Comments&BlockComments != 0
+	case symbol == '#':
+		return p.lexer.config.Comments&HashComments != 0
+	}
+
+	return false
+}
+
+func (p *Parser) parseComment(start int) Token {
+
+	l := p.lexer
+
+	l.PrevPos()
+
+	if l.HasPrefix("/*") {
+		token, ok := l.ReadDelimited("/*", "*/", COMMENT)
+		if !ok {
+			p.errorf(token.Start, token.End, "unterminated block comment")
+		}
+		return token
+	}
+
+	for {
+		r, ok := l.Peek()
+		if !ok || r == '\n' {
+			break
+		}
+		l.NextPos()
+	}
+
+	return Token{Type: COMMENT, Value: string(l.input[start:l.pos]), Start: start, End: l.pos}
+}
+
+func (p *Parser) isOperatorStart(start int) bool {
+	_, _, ok := p.lexer.operator(start)
+	return ok
+}
+
+func (p *Parser) parseOperator(start int) Token {
+
+	l := p.lexer
+
+	tokenType, n, _ := l.operator(start)
+
+	l.pos = start + n
+
+	return Token{Type: tokenType, Value: string(l.input[start:l.pos]), Start: start, End: l.pos}
+}
+
+func (p *Parser) isNumberStart(symbol rune, tokens []Token) bool {
+
+	next, _ := p.lexer.Peek()
+
+	switch symbol {
+	case '.':
+		return isDigitOf(next, 10) && !afterValue(tokens)
+	case '-', '+':
+		if next == '.' {
+			next, _ = p.lexer.PeekN(1)
+		}
+		return isDigitOf(next, 10) && !afterValue(tokens)
+	}
+
+	return isDigitOf(symbol, 10)
 }
 
 func (p *Parser) ParseText() Token {
@@ -198,6 +260,15 @@ func (p *Parser) ParsePlaceholder() (Token, bool) {
 		p.lexer.NextPos()
 	}
 
+	// An unterminated {# is read as an ordinary placeholder such as {#id}.
+	if p.lexer.HasPrefix("{#") {
+		mark := p.lexer.Mark()
+		if token, ok := p.lexer.ReadDelimited("{#", "#}", COMMENT); ok {
+			return token, true
+		}
+		p.lexer.Reset(mark)
+	}
+
 	token, ok := p.lexer.Read('{', '}', IDENT)
 	{
 		if !ok {
@@ -223,6 +294,16 @@ func (p *Parser) ParsePlaceholders() []Token {
 	return tokens
 }
 
+func placeholderKey(token Token) string {
+	value := token.Trim()
+	{
+		if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
+			value = value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
 func Filter[T any](input []T, fn func(T) bool) []T {
 	var output []T
 	for _, v := range input {
@@ -240,7 +321,7 @@ type Replacer interface {
 func ReplaceWithTokens[T Replacer](input string, tokens []Token, replacer T) string {
 
 	tokens = Filter(tokens, func(token Token) bool {
-		return token.Type == IDENT
+		return token.Type == IDENT || token.Type == COMMENT
 	})
 
 	if len(tokens) == 0 {
@@ -271,14 +352,14 @@ func ReplaceWithTokens[T Replacer](input string, tokens []Token, replacer T) str
 
 		segment := input[prevEnd:token.Start]
 
-		value := token.Trim()
+		var value string
 		{
-			if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
-				value = value[1 : len(value)-1]
+			if token.Type == IDENT {
+				value = repl(placeholderKey(token))
 			}
 		}
 
-		combined := segment + repl(value)
+		combined := segment + value
 
 		buff = append(buff, combined...)
 
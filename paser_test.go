@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"testing/quick"
 )
 
 func TestFindTexts(t *testing.T) {
@@ -494,3 +497,1230 @@ func TestReplaceWithTokens_ReplaceWithMap(t *testing.T) {
 		t.Errorf("Expected result to be 'id:1/name:John/age:25/salary:50000', got %s", result)
 	}
 }
+
+func TestValidateKeys_UpperSnake(t *testing.T) {
+
+	const EXP = "id:{userId}/name:{USER_NAME}/age:{user age}"
+
+	violations := ValidateKeys(EXP, UpperSnakeKeys)
+
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %v", violations)
+	}
+
+	if violations[0].Key != "userId" || violations[0].Suggestion != "USER_ID" {
+		t.Errorf("Expected userId -> USER_ID, got %v", violations[0])
+	}
+
+	if violations[0].Start != 3 || violations[0].End != 11 {
+		t.Errorf("Expected position [3:11], got [%d:%d]", violations[0].Start, violations[0].End)
+	}
+
+	if violations[1].Key != "user age" || violations[1].Suggestion != "USER_AGE" {
+		t.Errorf("Expected user age -> USER_AGE, got %v", violations[1])
+	}
+}
+
+func TestFixKeys(t *testing.T) {
+
+	const EXP = "id:{userId}/name:{user-name}/age:{user age}"
+
+	result, violations := FixKeys(EXP, KebabKeys)
+
+	if len(violations) != 2 {
+		t.Errorf("Expected 2 violations, got %v", violations)
+	}
+
+	if result != "id:{user-id}/name:{user-name}/age:{user-age}" {
+		t.Errorf("Expected result to be 'id:{user-id}/name:{user-name}/age:{user-age}', got %s", result)
+	}
+}
+
+func TestFixKeys_Modifiers(t *testing.T) {
+
+	const EXP = "{userId|upper} {name=Guest} { ID:int } {note?} {NAME?}"
+
+	result, violations := FixKeys(EXP, UpperSnakeKeys)
+
+	if len(violations) != 3 {
+		t.Errorf("Expected 3 violations, got %v", violations)
+	}
+
+	if result != "{USER_ID|upper} {NAME=Guest} { ID:int } {NOTE?} {NAME?}" {
+		t.Errorf("Expected modifiers to be kept, got %s", result)
+	}
+
+	if violations := ValidateKeys("{NAME?} {ID:int} {USER_ID=1|lower}", UpperSnakeKeys); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
+
+func TestNormalizeKeys_Collisions(t *testing.T) {
+
+	data := map[string]string{"userId": "camel", "user_id": "snake", "user-id": "kebab"}
+
+	for i := 0; i < 20; i++ {
+		replacer := NormalizeKeys(data, SnakeCase)
+		if result := replacer("UserID"); result != "kebab" {
+			t.Fatalf("Expected the first key in sorted order to win, got %s", result)
+		}
+		if result := replacer("userId"); result != "camel" {
+			t.Fatalf("Expected the exact key to win, got %s", result)
+		}
+		if result := replacer("user_id"); result != "snake" {
+			t.Fatalf("Expected the exact key to win, got %s", result)
+		}
+	}
+}
+
+func TestNormalizeKeys_CacheBound(t *testing.T) {
+
+	cache := newKeyCache(SnakeCase)
+
+	for i := 0; i < keyCacheSize*3; i++ {
+		if key := cache.get(fmt.Sprintf("key%dName", i)); key != fmt.Sprintf("key%d_name", i) {
+			t.Fatalf("Expected key%d_name, got %s", i, key)
+		}
+	}
+
+	if len(cache.keys) > keyCacheSize {
+		t.Errorf("Expected at most %d cached keys, got %d", keyCacheSize, len(cache.keys))
+	}
+}
+
+func TestReplaceWithTokens_NormalizeKeys(t *testing.T) {
+
+	const EXP = "{user_id}/{userId}/{user-id}/{UserID}/{User Name}"
+
+	parser := NewParser(EXP)
+
+	placeholders := parser.ParsePlaceholders()
+
+	replacer := NormalizeKeys(map[string]string{
+		"userId":   "1",
+		"userName": "John",
+	}, SnakeCase)
+
+	result := ReplaceWithTokens(EXP, placeholders, replacer)
+	if result != "1/1/1/1/John" {
+		t.Errorf("Expected result to be '1/1/1/1/John', got %s", result)
+	}
+}
+
+func TestPascalCase(t *testing.T) {
+	if result := PascalCase("user_name id"); result != "UserNameId" {
+		t.Errorf("Expected result to be 'UserNameId', got %s", result)
+	}
+}
+
+func TestTemplate_Variables(t *testing.T) {
+
+	const EXP = "Hi {name=Guest|upper}, id:{ID} ({ID}) note:{note?|trim|lower}"
+
+	variables := NewTemplate(EXP).Variables()
+
+	if len(variables) != 3 {
+		t.Fatalf("Expected 3 variables, got %v", variables)
+	}
+
+	name, id, note := variables[0], variables[1], variables[2]
+
+	if name.Key != "name" || !name.HasDefault || name.Default != "Guest" || !name.Optional {
+		t.Errorf("Unexpected name variable %+v", name)
+	}
+
+	if len(name.Filters) != 1 || name.Filters[0] != "upper" {
+		t.Errorf("Expected name filters [upper], got %v", name.Filters)
+	}
+
+	if id.Key != "ID" || id.Count != 2 || id.Optional || len(id.Positions) != 2 {
+		t.Errorf("Unexpected ID variable %+v", id)
+	}
+
+	if id.Positions[0] != (Position{Start: 26, End: 30}) {
+		t.Errorf("Expected first ID position [26:30], got %v", id.Positions[0])
+	}
+
+	if note.Key != "note" || !note.Optional || note.HasDefault || len(note.Filters) != 2 {
+		t.Errorf("Unexpected note variable %+v", note)
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+
+	tmpl := NewTemplate("Hi {name=Guest|upper}, id:{ID}{suffix?}")
+
+	result, err := Render(tmpl, map[string]string{"ID": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != "Hi GUEST, id:1" {
+		t.Errorf("Expected result to be 'Hi GUEST, id:1', got %s", result)
+	}
+
+	if _, err := Render(tmpl, map[string]string{}); err == nil {
+		t.Errorf("Expected missing ID error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	tmpl := NewTemplate("id:{ID:int}/name:{NAME}/age:{AGE:int?}/note:{NOTE?}")
+
+	problems := Validate(tmpl, map[string]string{
+		"ID":    "abc",
+		"AGE":   "25",
+		"EXTRA": "x",
+	})
+
+	if len(problems) != 3 {
+		t.Fatalf("Expected 3 problems, got %v", problems)
+	}
+
+	if problems[0].Kind != TypeMismatch || problems[0].Key != "ID" {
+		t.Errorf("Expected ID type mismatch, got %v", problems[0])
+	}
+
+	if problems[1].Kind != MissingKey || problems[1].Key != "NAME" {
+		t.Errorf("Expected NAME missing, got %v", problems[1])
+	}
+
+	if problems[2].Kind != UnusedKey || problems[2].Key != "EXTRA" {
+		t.Errorf("Expected EXTRA unused, got %v", problems[2])
+	}
+
+	if s := ProblemKind(99).String(); s != "ProblemKind(99)" {
+		t.Errorf("Expected ProblemKind(99), got %s", s)
+	}
+}
+
+func TestValidate_Struct(t *testing.T) {
+
+	type user struct {
+		ID   string `parser:"ID"`
+		Name string `parser:"NAME"`
+		Age  int    `parser:"AGE"`
+	}
+
+	tmpl := NewTemplate("id:{ID:int}/name:{NAME}/age:{AGE:bool}")
+
+	problems := Validate(tmpl, &user{ID: "1", Name: "John", Age: 25})
+
+	if len(problems) != 1 || problems[0].Kind != TypeMismatch || problems[0].Key != "AGE" {
+		t.Errorf("Expected AGE type mismatch, got %v", problems)
+	}
+
+	problems = Validate(tmpl, KeyFunc{Keys: []string{"ID", "NAME"}, Func: func(string) string { return "1" }})
+
+	if len(problems) != 1 || problems[0].Kind != MissingKey || problems[0].Key != "AGE" {
+		t.Errorf("Expected AGE missing, got %v", problems)
+	}
+}
+
+func TestCaseConversions(t *testing.T) {
+
+	const EXP = "hello_world userName"
+
+	cases := []struct {
+		name     string
+		fn       func(string) string
+		expected string
+	}{
+		{"CamelCase", CamelCase, "helloWorldUserName"},
+		{"PascalCase", PascalCase, "HelloWorldUserName"},
+		{"SnakeCase", SnakeCase, "hello_world_user_name"},
+		{"ScreamingSnakeCase", ScreamingSnakeCase, "HELLO_WORLD_USER_NAME"},
+		{"KebabCase", KebabCase, "hello-world-user-name"},
+		{"TrainCase", TrainCase, "Hello-World-User-Name"},
+		{"DotCase", DotCase, "hello.world.user.name"},
+		{"PathCase", PathCase, "hello/world/user/name"},
+		{"TitleCase", TitleCase, "Hello World User Name"},
+		{"SentenceCase", SentenceCase, "Hello world user name"},
+		{"FlatCase", FlatCase, "helloworldusername"},
+	}
+
+	for _, c := range cases {
+		if result := c.fn(EXP); result != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, result)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+
+	words := Words("  userName, 42")
+
+	expected := []string{"user", "Name", "42"}
+	if len(words) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, words)
+	}
+
+	for i, exp := range expected {
+		if words[i].Value != exp {
+			t.Errorf("Expected word %s, got %s", exp, words[i].Value)
+		}
+	}
+
+	if words[1].Start != 6 || words[1].End != 10 {
+		t.Errorf("Expected Name at [6:10], got [%d:%d]", words[1].Start, words[1].End)
+	}
+}
+
+func TestSnakeCase_Acronyms(t *testing.T) {
+
+	cases := map[string]string{
+		"HTTPServer":      "http_server",
+		"userID":          "user_id",
+		"UserID":          "user_id",
+		"v2Api":           "v2_api",
+		"ipv4Addr":        "ipv4_addr",
+		"JSONAPIResponse": "json_api_response",
+		"userIDs":         "user_ids",
+		"IDs":             "ids",
+		"URLs":            "urls",
+		"APIs":            "apis",
+		"UUIDv4":          "uuid_v4",
+		"iOS":             "ios",
+		"IDsByName":       "ids_by_name",
+		"xID":             "x_id",
+		"xPos":            "x_pos",
+		"HTTPSProxy":      "https_proxy",
+		"IDsomething":     "id_something",
+	}
+
+	for input, expected := range cases {
+		if result := SnakeCase(input); result != expected {
+			t.Errorf("SnakeCase(%q): expected %s, got %s", input, expected, result)
+		}
+	}
+}
+
+func TestSnakeCase_LongInitialismRun(t *testing.T) {
+
+	result := SnakeCase(strings.Repeat("ID", 10000))
+	if result != strings.Repeat("id_", 9999)+"id" {
+		t.Errorf("Expected 10000 ids, got %d bytes", len(result))
+	}
+
+	result = SnakeCase(strings.Repeat("JSONAPI", 1000) + "X")
+	if result != strings.ToLower(strings.Repeat("JSONAPI", 1000)+"X") {
+		t.Errorf("Expected an unsplittable run to stay whole, got %d bytes", len(result))
+	}
+
+	if result := SnakeCase(strings.Repeat("ID2", 5000)); len(result) != 19999 {
+		t.Errorf("Expected 5000 id2 words, got %d bytes", len(result))
+	}
+}
+
+func TestConvertCaseWith_PreserveAcronyms(t *testing.T) {
+
+	cases := map[string]string{
+		"HTTPServer": "HTTPServer",
+		"user_id":    "UserID",
+		"serve_http": "ServeHTTP",
+		"http_v2":    "HttpV2",
+	}
+
+	for input, expected := range cases {
+		if result := ConvertCaseWith(input, PascalStyle, Reversible); result != expected {
+			t.Errorf("PascalCase(%q): expected %s, got %s", input, expected, result)
+		}
+	}
+
+	if result := ConvertCaseWith("user_2fa", CamelStyle, Reversible); result != "user_2fa" {
+		t.Errorf("Expected result to be 'user_2fa', got %s", result)
+	}
+}
+
+func TestConvertCaseWith_RoundTrip(t *testing.T) {
+
+	pool := []string{
+		"user", "id", "http", "server", "url", "v2", "api", "ipv4",
+		"addr", "json", "name", "2fa", "xml", "order", "item", "count",
+		"uuid", "x11", "tls", "config",
+	}
+
+	styles := []CaseStyle{
+		CamelStyle, PascalStyle, KebabStyle, ScreamingSnakeStyle,
+		TrainStyle, DotStyle, PathStyle, TitleStyle, SentenceStyle,
+	}
+
+	property := func(picks []uint8) bool {
+
+		if len(picks) == 0 {
+			return true
+		}
+
+		words := make([]string, len(picks))
+		for i, pick := range picks {
+			words[i] = pool[int(pick)%len(pool)]
+		}
+
+		snake := strings.Join(words, "_")
+
+		for _, style := range styles {
+			converted := ConvertCaseWith(snake, style, Reversible)
+			if back := ConvertCaseWith(converted, SnakeStyle, Reversible); back != snake {
+				t.Logf("%s -> %s -> %s", snake, converted, back)
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAppendCamel_NoAllocs(t *testing.T) {
+
+	const EXP = "id:{ID}/name:{NAME}/age:${AGE}/salary:{SALARY} Testing_@@@testing _______testing"
+
+	dst := make([]byte, 0, 256)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = AppendCamel(dst[:0], EXP)
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+
+	if string(dst) != "idIdNameNameAgeAgeSalarySalaryTestingTestingTesting" {
+		t.Errorf("Unexpected result %s", dst)
+	}
+}
+
+func TestCamelCase_Unicode(t *testing.T) {
+
+	if result := CamelCase("привет_мир ÉCOLE"); result != "приветМирÉcole" {
+		t.Errorf("Expected result to be 'приветМирÉcole', got %s", result)
+	}
+
+	if result := string(AppendSnake([]byte("x="), "ÜberHTTPServer")); result != "x=über_http_server" {
+		t.Errorf("Expected result to be 'x=über_http_server', got %s", result)
+	}
+}
+
+func TestCamelCase_Deprecated(t *testing.T) {
+
+	const EXP = "user_id HTTPServer"
+
+	for _, fn := range []func(string) string{CamelCaseOptimized, CamelCaseUnsafe, CamelCaseUnsafe2, CamelCaseUnsafe3} {
+		if result := fn(EXP); result != CamelCase(EXP) {
+			t.Errorf("Expected %s, got %s", CamelCase(EXP), result)
+		}
+	}
+}
+
+func BenchmarkAppendCamel(b *testing.B) {
+	const EXP = `
+		id:{ID}/name:{NAME}/age:${AGE}/salary:{SALARY} id:{ID}/name:{NAME}/age:${AGE}/salary:{SALARY}Testing_@@@testing _______testing Testing_@@@testing _______testing Testing_@@@testing _______testingTesting_@@@testing _______testingTesting_@@@testing _______testing
+	`
+	dst := make([]byte, 0, len(EXP))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = AppendCamel(dst[:0], EXP)
+	}
+}
+
+func BenchmarkAppendCamel_Unicode(b *testing.B) {
+	const EXP = `
+		привет_мир:{ID}/имя:{NAME}/возраст:${AGE} Тестирование_@@@тест _______тест ÉcoleÜberStraße
+	`
+	dst := make([]byte, 0, len(EXP))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = AppendCamel(dst[:0], EXP)
+	}
+}
+
+func TestStringToBytes_Empty(t *testing.T) {
+
+	if b := StringToBytes(""); len(b) != 0 {
+		t.Errorf("Expected empty slice, got %v", b)
+	}
+
+	if s := BytesToString(nil); s != "" {
+		t.Errorf("Expected empty string, got %q", s)
+	}
+
+	if s := BytesToString([]byte{}); s != "" {
+		t.Errorf("Expected empty string, got %q", s)
+	}
+
+	if s := BytesToString(StringToBytes("user_id")); s != "user_id" {
+		t.Errorf("Expected user_id, got %q", s)
+	}
+}
+
+func TestCamelCaseUnsafe_Literal(t *testing.T) {
+
+	const EXP = "user_name"
+
+	if result := CamelCaseUnsafe(EXP); result != "userName" {
+		t.Errorf("Expected result to be 'userName', got %s", result)
+	}
+
+	if EXP != "user_name" {
+		t.Errorf("Input literal was modified: %s", EXP)
+	}
+
+	if result := CamelCaseUnsafe(""); result != "" {
+		t.Errorf("Expected empty result, got %q", result)
+	}
+}
+
+func TestCaseConversions_Concurrent(t *testing.T) {
+
+	const EXP = "HTTPServer user_id привет_мир"
+
+	replacer := NormalizeKeys(map[string]string{"userId": "1"}, SnakeCase)
+
+	done := make(chan struct{})
+
+	for i := 0; i < 8; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 100; j++ {
+				_ = CamelCaseUnsafe(EXP)
+				_ = SnakeCase(EXP)
+				_ = BytesToString(StringToBytes(EXP))
+				_ = replacer("user_id")
+			}
+		}()
+	}
+
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+}
+
+func TestConvertCaseWith_Language(t *testing.T) {
+
+	cases := []struct {
+		input    string
+		style    CaseStyle
+		lang     string
+		expected string
+	}{
+		{"Istanbul ili", SnakeStyle, "tr", "ıstanbul_ili"},
+		{"istanbul ili", ScreamingSnakeStyle, "tr-TR", "İSTANBUL_İLİ"},
+		{"istanbul ili", ScreamingSnakeStyle, "", "ISTANBUL_ILI"},
+		{"straße name", ScreamingSnakeStyle, "de-DE", "STRASSE_NAME"},
+		{"ΟΔΟΣ ΣΟΦΙΑΣ", SnakeStyle, "el", "οδος_σοφιας"},
+		{"οδός σοφίας", ScreamingSnakeStyle, "el", "ΟΔΟΣ_ΣΟΦΙΑΣ"},
+	}
+
+	for _, c := range cases {
+		result := ConvertCaseWith(c.input, c.style, CaseOptions{Language: c.lang})
+		if result != c.expected {
+			t.Errorf("%q [%s]: expected %s, got %s", c.input, c.lang, c.expected, result)
+		}
+	}
+}
+
+func TestConvertCaseWith_Transliterate(t *testing.T) {
+
+	opts := CaseOptions{Transliterate: DefaultTranslit}
+
+	cases := []struct {
+		input    string
+		style    CaseStyle
+		expected string
+	}{
+		{"Привет мир", SnakeStyle, "privet_mir"},
+		{"ЩУКА и Щука", KebabStyle, "shchuka-i-shchuka"},
+		{"Ўзбекистон қишлоғи", CamelStyle, "ozbekistonQishlogi"},
+		{"Καλημέρα κόσμε", SnakeStyle, "kalimera_kosme"},
+		{"Crème Brûlée", PascalStyle, "CremeBrulee"},
+		{"Straße", SnakeStyle, "strasse"},
+	}
+
+	for _, c := range cases {
+		if result := ConvertCaseWith(c.input, c.style, opts); result != c.expected {
+			t.Errorf("%q: expected %s, got %s", c.input, c.expected, result)
+		}
+	}
+
+	custom := CaseOptions{Transliterate: TranslitTable{'ä': "ae", 'ö': "oe", 'ü': "ue"}}
+	if result := ConvertCaseWith("Größe Über", SnakeStyle, custom); result != "groeße_ueber" {
+		t.Errorf("Expected groeße_ueber, got %s", result)
+	}
+
+	if result := Transliterate("Äb äb", TranslitTable{'ä': "æ"}); result != "Æb æb" {
+		t.Errorf("Expected Æb æb, got %q", result)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+
+	cases := []struct {
+		input    string
+		opts     SlugOptions
+		expected string
+	}{
+		{"Hello, World! 2024", SlugOptions{}, "hello-world-2024"},
+		{"  --Go & iPhone15 -- ", SlugOptions{}, "go-iphone15"},
+		{"The quick brown fox", SlugOptions{MaxLength: 13}, "the-quick"},
+		{"Supercalifragilistic", SlugOptions{MaxLength: 5}, "super"},
+		{"Hello World", SlugOptions{Separator: "_"}, "hello_world"},
+		{"Привет мир", SlugOptions{Transliterate: DefaultTranslit}, "privet-mir"},
+	}
+
+	for _, c := range cases {
+		if result := Slugify(c.input, c.opts); result != c.expected {
+			t.Errorf("%q: expected %s, got %s", c.input, c.expected, result)
+		}
+	}
+}
+
+func TestSlugify_Unique(t *testing.T) {
+
+	taken := map[string]bool{"title": true}
+
+	opts := SlugOptions{Taken: taken}
+
+	if result := Slugify("Title", opts); result != "title-2" {
+		t.Errorf("Expected title-2, got %s", result)
+	}
+
+	if result := Slugify("Title!", opts); result != "title-3" {
+		t.Errorf("Expected title-3, got %s", result)
+	}
+
+	if !taken["title-2"] || !taken["title-3"] {
+		t.Errorf("Expected new slugs to be added to taken, got %v", taken)
+	}
+
+	opts.MaxLength = 7
+	if result := Slugify("Title long", opts); result != "title-4" {
+		t.Errorf("Expected title-4, got %s", result)
+	}
+
+	taken = map[string]bool{"ti": true}
+	if result := Slugify("Title", SlugOptions{MaxLength: 2, Taken: taken}); result != "2" {
+		t.Errorf("Expected 2, got %s", result)
+	}
+
+	taken = map[string]bool{"t": true}
+	for n := 2; n < 10; n++ {
+		taken[fmt.Sprint(n)] = true
+	}
+	if result := Slugify("Title", SlugOptions{MaxLength: 1, Taken: taken}); result != "" {
+		t.Errorf("Expected no slug to fit, got %s", result)
+	}
+}
+
+func tokenTypes(tokens []Token) []TokenType {
+	types := make([]TokenType, len(tokens))
+	for i, token := range tokens {
+		types[i] = token.Type
+	}
+	return types
+}
+
+func equalTypes(a, b []TokenType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParse_IdentModes(t *testing.T) {
+
+	const EXP = "user_id2 _x"
+
+	cases := []struct {
+		mode     IdentMode
+		expected []TokenType
+	}{
+		{IdentLetters, []TokenType{IDENT, UNDERSCORE, IDENT, NUMBER, SPACE, UNDERSCORE, IDENT, EOF}},
+		{IdentGo, []TokenType{IDENT, SPACE, IDENT, EOF}},
+		{IdentUnicode, []TokenType{IDENT, SPACE, UNDERSCORE, IDENT, EOF}},
+	}
+
+	for _, c := range cases {
+		tokens := NewParserWithConfig(EXP, LexerConfig{Ident: c.mode}).Parse()
+		if types := tokenTypes(tokens); !equalTypes(types, c.expected) {
+			t.Errorf("mode %d: expected %v, got %v", c.mode, c.expected, types)
+		}
+	}
+
+	tokens := NewParserWithConfig("naïve_çafé२ x", LexerConfig{Ident: IdentUnicode}).Parse()
+	if tokens[0].Type != IDENT || tokens[0].Value != "naïve_çafé२" {
+		t.Errorf("Expected IDENT naïve_çafé२, got %v", tokens[0])
+	}
+}
+
+func TestParse_Strings(t *testing.T) {
+
+	const EXP = `name="a\"b\né😀" 'it\'s' ` + "`raw\\n\nline`"
+
+	p := NewParserWithConfig(EXP, LexerConfig{Strings: true})
+
+	tokens := Filter(p.Parse(), func(token Token) bool {
+		return token.Type == STRING
+	})
+
+	if len(tokens) != 3 {
+		t.Fatalf("Expected 3 strings, got %v", tokens)
+	}
+
+	if tokens[0].Value != "a\"b\né😀" || tokens[0].Raw != `"a\"b\né😀"` {
+		t.Errorf("Unexpected double quoted string %q (raw %q)", tokens[0].Value, tokens[0].Raw)
+	}
+
+	if tokens[1].Value != "it's" {
+		t.Errorf("Expected it's, got %q", tokens[1].Value)
+	}
+
+	if tokens[2].Value != "raw\\n\nline" {
+		t.Errorf("Expected raw string, got %q", tokens[2].Value)
+	}
+
+	if len(p.Errors()) != 0 {
+		t.Errorf("Expected no errors, got %v", p.Errors())
+	}
+
+	tokens = NewParserWithConfig(`"\u00e9\uD83D\uDE00\x41\101\t"`, LexerConfig{Strings: true}).Parse()
+	if tokens[0].Value != "é😀AA\t" {
+		t.Errorf("Expected decoded escapes, got %q", tokens[0].Value)
+	}
+}
+
+func TestParse_StringErrors(t *testing.T) {
+
+	p := NewParserWithConfig(`"open \q`+"\nnext", LexerConfig{Strings: true})
+
+	tokens := p.Parse()
+
+	if tokens[0].Type != INVALID || tokens[0].Value != `"open \q` {
+		t.Errorf("Expected INVALID unterminated literal, got %v", tokens[0])
+	}
+
+	if len(p.Errors()) != 2 {
+		t.Errorf("Expected 2 errors, got %v", p.Errors())
+	}
+
+	if types := tokenTypes(NewParser(`"a"`).Parse()); !equalTypes(types, []TokenType{QUOTE, IDENT, QUOTE, EOF}) {
+		t.Errorf("Expected default mode to keep QUOTE tokens, got %v", types)
+	}
+}
+
+func TestParse_InvalidCodePoints(t *testing.T) {
+
+	for _, input := range []string{`"\uD800"`, `"\uDC00\uD800"`, `"\uD800\uD800"`, `"\UFFFFFFFF"`, `"\U0000D800"`, `"\U00110000"`} {
+		p := NewParserWithConfig(input, LexerConfig{Strings: true})
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("Expected invalid code point error for %s", input)
+		}
+	}
+
+	p := NewParserWithConfig(`"\U0010FFFF"`, LexerConfig{Strings: true})
+	if tokens := p.Parse(); tokens[0].Value != "\U0010FFFF" || len(p.Errors()) != 0 {
+		t.Errorf("Expected U+10FFFF, got %q %v", tokens[0].Value, p.Errors())
+	}
+}
+
+func TestParse_Numbers(t *testing.T) {
+
+	const EXP = "3.14 -5 1e9 0xFF 1_000 0o17 0755 0b101 .5 2.5E-3 a-5 items.1.name"
+
+	tokens := Filter(NewParserWithConfig(EXP, LexerConfig{Numbers: true}).Parse(), func(token Token) bool {
+		return token.Type == NUMBER
+	})
+
+	expected := []struct {
+		value string
+		kind  NumberKind
+	}{
+		{"3.14", NumberFloat}, {"-5", NumberInt}, {"1e9", NumberFloat},
+		{"0xFF", NumberHex}, {"1_000", NumberInt}, {"0o17", NumberOctal},
+		{"0755", NumberOctal}, {"0b101", NumberBinary}, {".5", NumberFloat},
+		{"2.5E-3", NumberFloat}, {"5", NumberInt}, {"1", NumberInt},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d numbers, got %v", len(expected), tokens)
+	}
+
+	for i, exp := range expected {
+		if tokens[i].Value != exp.value || tokens[i].Kind != exp.kind {
+			t.Errorf("Expected %s (%d), got %s (%d)", exp.value, exp.kind, tokens[i].Value, tokens[i].Kind)
+		}
+	}
+
+	prefixes := []struct {
+		input  string
+		value  string
+		kind   NumberKind
+		errors int
+	}{
+		{"0x", "0", NumberInt, 0},
+		{"0o8", "0", NumberInt, 0},
+		{"0b2", "0", NumberInt, 0},
+		{"089", "089", NumberInt, 1},
+		{"-0", "-0", NumberInt, 0},
+		{"-017", "-017", NumberOctal, 0},
+	}
+
+	for _, c := range prefixes {
+		p := NewParserWithConfig(c.input, LexerConfig{Numbers: true})
+		token := p.Parse()[0]
+		if token.Type != NUMBER || token.Value != c.value || token.Kind != c.kind || len(p.Errors()) != c.errors {
+			t.Errorf("%s: expected %s (%s) with %d errors, got %v (%s) %v", c.input, c.value, c.kind, c.errors, token, token.Kind, p.Errors())
+		}
+	}
+}
+
+func TestToken_IntFloat(t *testing.T) {
+
+	tokens := NewParserWithConfig("0xFF 1_000 -2.5 0755", LexerConfig{Numbers: true}).Parse()
+
+	if v, err := tokens[0].Int(); err != nil || v != 255 {
+		t.Errorf("Expected 255, got %d (%v)", v, err)
+	}
+
+	if v, err := tokens[2].Float(); err != nil || v != 1000 {
+		t.Errorf("Expected 1000, got %v (%v)", v, err)
+	}
+
+	if v, err := tokens[4].Float(); err != nil || v != -2.5 {
+		t.Errorf("Expected -2.5, got %v (%v)", v, err)
+	}
+
+	if _, err := tokens[4].Int(); err == nil {
+		t.Errorf("Expected error for Int() on a float")
+	}
+
+	if v, err := tokens[6].Int(); err != nil || v != 0755 {
+		t.Errorf("Expected 493, got %d (%v)", v, err)
+	}
+}
+
+func TestParse_Keywords(t *testing.T) {
+
+	tokens := Filter(NewParserWithConfig("true False null nil name", LexerConfig{Keywords: DefaultKeywords}).Parse(), func(token Token) bool {
+		return token.Type != SPACE
+	})
+
+	if types := tokenTypes(tokens); !equalTypes(types, []TokenType{BOOL, IDENT, NULL, NULL, IDENT, EOF}) {
+		t.Errorf("Unexpected types %v", types)
+	}
+
+	const IF TokenType = 100
+
+	keywords := map[string]TokenType{"true": BOOL, "IF": IF}
+
+	tokens = Filter(NewParserWithConfig("TRUE if x", LexerConfig{Keywords: keywords, FoldKeywords: true}).Parse(), func(token Token) bool {
+		return token.Type != SPACE
+	})
+
+	if types := tokenTypes(tokens); !equalTypes(types, []TokenType{BOOL, IF, IDENT, EOF}) {
+		t.Errorf("Unexpected types %v", types)
+	}
+}
+
+func TestTemplate_Comments(t *testing.T) {
+
+	const EXP = "{# greeting, keep it short #}Hi {NAME}{# {NAME} is the first name #}!"
+
+	tmpl := NewTemplate(EXP)
+
+	if len(tmpl.Comments()) != 2 || tmpl.Comments()[0].Type != COMMENT {
+		t.Fatalf("Expected 2 comments, got %v", tmpl.Comments())
+	}
+
+	if variables := tmpl.Variables(); len(variables) != 1 || variables[0].Count != 1 {
+		t.Errorf("Expected NAME once, got %v", variables)
+	}
+
+	result, err := Render(tmpl, map[string]string{"NAME": "John"})
+	if err != nil || result != "Hi John!" {
+		t.Errorf("Expected result to be 'Hi John!', got %s (%v)", result, err)
+	}
+
+	result = ReplaceWithTokens(EXP, NewParser(EXP).ParsePlaceholders(), map[string]string{"NAME": "John"})
+	if result != "Hi John!" {
+		t.Errorf("Expected result to be 'Hi John!', got %s", result)
+	}
+}
+
+func TestTemplate_UnterminatedComment(t *testing.T) {
+
+	tokens := NewParser("{#id} and {name}").ParsePlaceholders()
+	if len(tokens) != 2 || tokens[0].Type != IDENT || tokens[0].Value != "{#id}" || tokens[1].Value != "{name}" {
+		t.Errorf("Expected placeholders {#id} and {name}, got %v", tokens)
+	}
+
+	tmpl := NewTemplate("Hi {#name}")
+	if len(tmpl.Comments()) != 0 || tmpl.Placeholders()[0].Key != "#name" {
+		t.Errorf("Expected {#name} to be a placeholder, got %v", tmpl.Placeholders())
+	}
+
+	if _, err := Render(tmpl, map[string]string{}); err == nil {
+		t.Errorf("Expected missing value error")
+	}
+}
+
+func TestParse_Comments(t *testing.T) {
+
+	const EXP = "a // line\nb # hash\nc /* block\n */ d / e"
+
+	tokens := Filter(NewParserWithConfig(EXP, LexerConfig{Comments: SlashComments | HashComments | BlockComments}).Parse(), func(token Token) bool {
+		return token.Type == COMMENT
+	})
+
+	expected := []string{"// line", "# hash", "/* block\n */"}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, tokens)
+	}
+
+	for i, exp := range expected {
+		if tokens[i].Value != exp {
+			t.Errorf("Expected %q, got %q", exp, tokens[i].Value)
+		}
+	}
+
+	p := NewParserWithConfig("a /* open", LexerConfig{Comments: BlockComments})
+	if tokens := p.Parse(); tokens[2].Type != COMMENT || len(p.Errors()) != 1 {
+		t.Errorf("Expected unterminated comment error, got %v %v", tokens, p.Errors())
+	}
+}
+
+func TestParse_Operators(t *testing.T) {
+
+	const EXP = "a==b != c<=d>=e && f||g -> h::i <== [j] $k ~`"
+
+	tokens := Filter(NewParserWithConfig(EXP, LexerConfig{Operators: DefaultOperators}).Parse(), func(token Token) bool {
+		return token.Type != SPACE && token.Type != IDENT
+	})
+
+	expected := []TokenType{EQ, NEQ, LTE, GTE, AND, BAR, ARROW, DCOLON, LTE, ASSIGN, LBRACKET, RBRACKET, DOLLAR, TILDE, BACKTICK, EOF}
+	if !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v, got %v", expected, tokenTypes(tokens))
+	}
+
+	if tokens[8].Value != "<=" || tokens[8].Start != 32 || tokens[8].End != 34 {
+		t.Errorf("Expected '<=' at [32:34], got %v", tokens[8])
+	}
+
+	tokens = Filter(NewParser("a==b").Parse(), func(token Token) bool {
+		return token.Type != IDENT
+	})
+
+	if expected := []TokenType{ASSIGN, ASSIGN, EOF}; !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v without operators, got %v", expected, tokenTypes(tokens))
+	}
+
+	tokens = NewParserWithConfig("a...b", LexerConfig{Operators: map[string]TokenType{"..": PERIOD, "...": ARROW}}).Parse()
+	if tokens[1].Type != ARROW || tokens[1].Value != "..." {
+		t.Errorf("Expected longest match '...', got %v", tokens[1])
+	}
+}
+
+func TestParse_Punctuation(t *testing.T) {
+
+	tokens := NewParser("a|b").Parse()
+	if tokens[1].Type != PIPE {
+		t.Errorf("Expected PIPE, got %v", tokens[1])
+	}
+
+	tokens = NewParser("a||b").Parse()
+	if expected := []TokenType{IDENT, BAR, IDENT, EOF}; !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v by default, got %v", expected, tokenTypes(tokens))
+	}
+
+	tokens = NewParserWithConfig("a||b", LexerConfig{Operators: map[string]TokenType{}}).Parse()
+	if expected := []TokenType{IDENT, PIPE, PIPE, IDENT, EOF}; !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v without operators, got %v", expected, tokenTypes(tokens))
+	}
+
+	if tokenType, ok := ParseTokenType("OR"); !ok || tokenType != BAR || OR != BAR {
+		t.Errorf("Expected OR to be an alias of BAR, got %v", tokenType)
+	}
+
+	tokens = NewParserWithConfig("a||b|c", LexerConfig{Operators: DefaultOperators}).Parse()
+	if expected := []TokenType{IDENT, BAR, IDENT, PIPE, IDENT, EOF}; !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v, got %v", expected, tokenTypes(tokens))
+	}
+
+	config := LexerConfig{Punctuation: map[rune]TokenType{'€': DOLLAR, '$': IDENT, '@': INVALID}}
+
+	tokens = NewParserWithConfig("€$@#", config).Parse()
+	if expected := []TokenType{DOLLAR, IDENT, INVALID, HASH, EOF}; !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v, got %v", expected, tokenTypes(tokens))
+	}
+
+	if DefaultPunctuation['$'] != DOLLAR {
+		t.Errorf("Expected DefaultPunctuation to be left untouched")
+	}
+}
+
+func TestParse_Rules(t *testing.T) {
+
+	const EXP = "mail bob@example.com about v1.2.3 on 2024-01-31 #release"
+
+	p := NewParserWithConfig(EXP, LexerConfig{Rules: []Rule{
+		RegexpRule(0, STRING, `[\w.+-]+@[\w-]+(\.[\w-]+)+`),
+		RegexpRule(0, NUMBER, `\d{4}-\d{2}-\d{2}`),
+	}})
+
+	p.AddRule(RegexpRule(10, TEXT, `v\d+\.\d+\.\d+`))
+	p.AddRule(Rule{Match: func(input []rune) (TokenType, int) {
+		if input[0] != '#' {
+			return INVALID, 0
+		}
+		n := 1
+		for n < len(input) && input[n] != ' ' {
+			n++
+		}
+		return COMMENT, n
+	}})
+
+	tokens := Filter(p.Parse(), func(token Token) bool {
+		return token.Type != SPACE && token.Type != IDENT
+	})
+
+	expected := []string{"bob@example.com", "v1.2.3", "2024-01-31", "#release", "EOF"}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, tokens)
+	}
+
+	for i, exp := range expected {
+		if tokens[i].Value != exp {
+			t.Errorf("Expected %q, got %q", exp, tokens[i].Value)
+		}
+	}
+
+	if tokens[1].Type != TEXT || tokens[1].Start != 27 || tokens[1].End != 33 {
+		t.Errorf("Expected TEXT at [27:33], got %v", tokens[1])
+	}
+
+	tokens = NewParserWithConfig("ünïcode@ß.de", LexerConfig{Rules: []Rule{RegexpRule(0, STRING, `\S+@\S+`)}}).Parse()
+	if tokens[0].Type != STRING || tokens[0].End != 12 {
+		t.Errorf("Expected STRING at [0:12], got %v", tokens[0])
+	}
+}
+
+func TestTokenType_Register(t *testing.T) {
+
+	email := RegisterTokenType("EMAIL")
+
+	if email != RegisterTokenType("EMAIL") {
+		t.Errorf("Expected registering EMAIL twice to return the same type")
+	}
+
+	if RegisterTokenType("IDENT") != IDENT {
+		t.Errorf("Expected built-in name to return its type")
+	}
+
+	if email.String() != "EMAIL" || PERIOD.String() != "PERIOD" {
+		t.Errorf("Expected EMAIL and PERIOD, got %s and %s", email, PERIOD)
+	}
+
+	if tokenType, ok := ParseTokenType("EMAIL"); !ok || tokenType != email {
+		t.Errorf("Expected ParseTokenType to find EMAIL, got %v %v", tokenType, ok)
+	}
+
+	if _, ok := ParseTokenType("NOPE"); ok {
+		t.Errorf("Expected ParseTokenType to reject NOPE")
+	}
+
+	if s := TokenType(-1).String(); s != "TokenType(-1)" {
+		t.Errorf("Expected TokenType(-1), got %s", s)
+	}
+
+	token := NewParserWithConfig("bob@example.com", LexerConfig{Rules: []Rule{RegexpRule(0, email, `\S+@\S+`)}}).Parse()[0]
+	if s := token.String(); s != "EMAIL: bob@example.com [0:15]\n" {
+		t.Errorf("Expected EMAIL token, got %q", s)
+	}
+
+	if _, err := (Token{Type: TokenType(1000), Value: "x"}).Int(); err == nil || !strings.Contains(err.Error(), "TokenType(1000)") {
+		t.Errorf("Expected error naming TokenType(1000), got %v", err)
+	}
+}
+
+func TestToken_JSON(t *testing.T) {
+
+	tokens := NewParserWithConfig(`a = 0xFF "x\ty"`, LexerConfig{Numbers: true, Strings: true}).Parse()
+
+	data, err := json.Marshal(tokens[4])
+	if err != nil || string(data) != `{"type":"NUMBER","value":"0xFF","kind":"hex","start":4,"end":8}` {
+		t.Errorf("Unexpected JSON %s (%v)", data, err)
+	}
+
+	data, err = json.Marshal(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []Token
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(tokens) {
+		t.Fatalf("Expected %d tokens, got %d", len(tokens), len(decoded))
+	}
+
+	for i := range tokens {
+		if decoded[i] != tokens[i] {
+			t.Errorf("Expected %v, got %v", tokens[i], decoded[i])
+		}
+	}
+
+	var tokenType TokenType
+	if err := tokenType.UnmarshalText([]byte("TokenType(99)")); err != nil || tokenType != 99 {
+		t.Errorf("Expected TokenType(99), got %v (%v)", tokenType, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type":"NOPE"}`), &Token{}); err == nil {
+		t.Errorf("Expected error for unknown token type")
+	}
+
+	if err := json.Unmarshal([]byte(`{"type":"NUMBER","kind":"roman"}`), &Token{}); err == nil {
+		t.Errorf("Expected error for unknown number kind")
+	}
+}
+
+func TestDumpTokens(t *testing.T) {
+
+	tokens := NewParser("a=1").Parse()
+
+	var b strings.Builder
+
+	if err := DumpTokens(&b, tokens, DumpJSONLines); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"type":"IDENT","value":"a","start":0,"end":1}
+{"type":"ASSIGN","value":"=","start":1,"end":2}
+{"type":"NUMBER","value":"1","kind":"int","start":2,"end":3}
+{"type":"EOF","value":"EOF","start":3,"end":3}
+`
+	if b.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, b.String())
+	}
+
+	b.Reset()
+
+	if err := DumpTokens(&b, tokens[:2], DumpTable); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `TYPE    VALUE  START  END
+IDENT   "a"    0      1
+ASSIGN  "="    1      2
+`
+	if b.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, b.String())
+	}
+
+	b.Reset()
+
+	if err := DumpTokens(&b, nil, DumpJSON); err != nil || b.String() != "[]\n" {
+		t.Errorf("Expected empty array, got %q (%v)", b.String(), err)
+	}
+
+	if err := DumpTokens(&b, tokens, DumpFormat(9)); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestParse_Space(t *testing.T) {
+
+	const EXP = "  a \t= 1\r\n\n\tb  \n"
+
+	tokens := NewParserWithConfig(EXP, LexerConfig{Space: CoalesceSpace}).Parse()
+
+	expected := []TokenType{SPACE, IDENT, SPACE, ASSIGN, SPACE, NUMBER, NEWLINE, SPACE, IDENT, SPACE, NEWLINE, EOF}
+	if !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v, got %v", expected, tokenTypes(tokens))
+	}
+
+	if tokens[6].Value != "\r\n\n" || tokens[6].Start != 8 || tokens[6].End != 11 {
+		t.Errorf("Expected NEWLINE at [8:11], got %v", tokens[6])
+	}
+
+	tokens = NewParserWithConfig(EXP, LexerConfig{Space: DropSpace}).Parse()
+	if expected := []TokenType{IDENT, ASSIGN, NUMBER, IDENT, EOF}; !equalTypes(tokenTypes(tokens), expected) {
+		t.Errorf("Expected %v, got %v", expected, tokenTypes(tokens))
+	}
+
+	tokens = NewParserWithConfig(EXP, LexerConfig{Space: TriviaSpace}).Parse()
+
+	var b strings.Builder
+	for _, token := range tokens {
+		if token.Type != EOF {
+			b.WriteString(token.Leading + token.Value + token.Trailing)
+		}
+	}
+
+	if b.String() != EXP {
+		t.Errorf("Expected trivia to round-trip, got %q", b.String())
+	}
+
+	if tokens[2].Trailing != "\r\n" || tokens[3].Leading != "\n\t" || tokens[3].Trailing != "  \n" {
+		t.Errorf("Unexpected trivia %q %q %q", tokens[2].Trailing, tokens[3].Leading, tokens[3].Trailing)
+	}
+
+	tokens = NewParserWithConfig("a -5", LexerConfig{Numbers: true, Space: DropSpace}).Parse()
+	if tokens[1].Type != NUMBER || tokens[1].Value != "-5" {
+		t.Errorf("Expected NUMBER -5, got %v", tokens[1])
+	}
+}
+
+func TestLexer_Backtracking(t *testing.T) {
+
+	l := NewLexer("héllo")
+
+	if l.Len() != 5 || l.Pos() != 0 || l.Remaining() != 5 {
+		t.Errorf("Expected 5 runes at 0, got %d at %d", l.Len(), l.Pos())
+	}
+
+	l.NextPosN(2)
+	mark := l.Mark()
+
+	l.NextPosN(10)
+	if l.Pos() != 5 || l.Remaining() != 0 {
+		t.Errorf("Expected position clamped to 5, got %d", l.Pos())
+	}
+
+	if _, ok := l.Next(); ok {
+		t.Errorf("Expected no rune at the end")
+	}
+
+	l.Reset(mark)
+	if r, _ := l.Peek(); r != 'l' || l.Pos() != 2 {
+		t.Errorf("Expected 'l' at 2, got %q at %d", r, l.Pos())
+	}
+
+	if r, ok := l.PeekN(-1); !ok || r != 'é' {
+		t.Errorf("Expected 'é' before, got %q", r)
+	}
+
+	if _, ok := l.PeekN(-3); ok {
+		t.Errorf("Expected no rune before the start")
+	}
+
+	l.PrevPosN(10)
+	if l.Pos() != 0 {
+		t.Errorf("Expected position clamped to 0, got %d", l.Pos())
+	}
+
+	l.PrevPos()
+	if r, _ := l.Next(); r != 'h' {
+		t.Errorf("Expected 'h', got %q", r)
+	}
+}
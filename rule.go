@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Rule recognizes a domain token such as an email or a semver string. Match
+// receives the input from the current position and returns the token type and
+// its length in runes, or a length of 0 when the rule does not apply.
+//
+// Rules run before the built-in token classes, highest Priority first; rules
+// of equal Priority run in the order they were added and the first match wins.
+type Rule struct {
+	Priority int
+	Match    func(input []rune) (TokenType, int)
+}
+
+// RegexpRule returns a Rule matching expr anchored at the current position.
+// It panics if expr does not compile.
+func RegexpRule(priority int, tokenType TokenType, expr string) Rule {
+
+	re := regexp.MustCompile(`\A(?:` + expr + `)`)
+
+	return Rule{
+		Priority: priority,
+		Match: func(input []rune) (TokenType, int) {
+			loc := re.FindReaderIndex(&runeReader{input: input})
+			if loc == nil {
+				return INVALID, 0
+			}
+			return tokenType, runeCount(input, loc[1])
+		},
+	}
+}
+
+type runeReader struct {
+	input []rune
+	pos   int
+}
+
+func (r *runeReader) ReadRune() (rune, int, error) {
+	if r.pos >= len(r.input) {
+		return 0, 0, io.EOF
+	}
+	ch := r.input[r.pos]
+	r.pos++
+	return ch, runeWidth(ch), nil
+}
+
+// runeWidth is the UTF-8 length of r, counting invalid runes as RuneError.
+func runeWidth(r rune) int {
+	if n := utf8.RuneLen(r); n > 0 {
+		return n
+	}
+	return utf8.RuneLen(utf8.RuneError)
+}
+
+// runeCount returns how many runes of input make up its first size bytes.
+func runeCount(input []rune, size int) int {
+	n := 0
+	for bytes := 0; bytes < size; n++ {
+		bytes += runeWidth(input[n])
+	}
+	return n
+}
+
+func (l *Lexer) AddRule(rule Rule) {
+
+	l.rules = append(l.rules, rule)
+
+	sort.SliceStable(l.rules, func(i, j int) bool {
+		return l.rules[i].Priority > l.rules[j].Priority
+	})
+}
+
+func (p *Parser) AddRule(rule Rule) {
+	p.lexer.AddRule(rule)
+}
+
+// rule returns the token of the first rule matching at start.
+func (l *Lexer) rule(start int) (Token, bool) {
+
+	for _, rule := range l.rules {
+		tokenType, n := rule.Match(l.input[start:])
+		if n <= 0 {
+			continue
+		}
+
+		n = min(n, len(l.input)-start)
+
+		l.pos = start + n
+
+		return Token{Type: tokenType, Value: string(l.input[start:l.pos]), Start: start, End: l.pos}, true
+	}
+
+	return Token{}, false
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type SlugOptions struct {
+	Separator     string          // Between words, "-" when empty
+	MaxLength     int             // In runes, cut on a word boundary; 0 means no limit
+	Transliterate TranslitTable   // Applied before splitting words, e.g. DefaultTranslit
+	Taken         map[string]bool // Slugs already in use; the result is made unique and added
+}
+
+// Slugify lowercases the words and numbers Parser.Parse finds in s and joins
+// them with the separator, dropping punctuation and symbols:
+//
+//	"Hello, World! 2024" -> hello-world-2024
+//
+// With Taken set, a slug already in use gets a numeric suffix: title, title-2.
+// When no unique slug fits in MaxLength, Slugify returns an empty string.
+func Slugify(s string, opts SlugOptions) string {
+
+	sep := opts.Separator
+	{
+		if sep == "" {
+			sep = "-"
+		}
+	}
+
+	if opts.Transliterate != nil {
+		s = Transliterate(s, opts.Transliterate)
+	}
+
+	words := slugWords(s)
+
+	slug := joinSlug(words, sep, opts.MaxLength)
+
+	if opts.Taken == nil || slug == "" {
+		return slug
+	}
+
+	unique := slug
+	for n := 2; opts.Taken[unique]; n++ {
+		unique = slug + sep + strconv.Itoa(n)
+		if opts.MaxLength <= 0 {
+			continue
+		}
+
+		// The base shrinks to make room for the suffix; once nothing of it
+		// is left the number alone is the slug.
+		number := strconv.Itoa(n)
+		if len(number) > opts.MaxLength {
+			return ""
+		}
+
+		budget := opts.MaxLength - utf8.RuneCountInString(sep+number)
+		if budget <= 0 {
+			unique = number
+			continue
+		}
+		unique = joinSlug(words, sep, budget) + sep + number
+	}
+
+	opts.Taken[unique] = true
+
+	return unique
+}
+
+func slugWords(s string) []string {
+
+	var words []string
+
+	prevEnd := -1
+
+	for _, token := range NewParser(s).Parse() {
+
+		if token.Type != IDENT && token.Type != NUMBER {
+			continue
+		}
+
+		word := strings.ToLower(token.Value)
+
+		if token.Start == prevEnd {
+			words[len(words)-1] += word
+		} else {
+			words = append(words, word)
+		}
+
+		prevEnd = token.End
+	}
+
+	return words
+}
+
+func joinSlug(words []string, sep string, maxLength int) string {
+
+	var b strings.Builder
+
+	length := 0
+
+	for i, word := range words {
+
+		n := utf8.RuneCountInString(word)
+		if i > 0 {
+			n += utf8.RuneCountInString(sep)
+		}
+
+		if maxLength > 0 && length+n > maxLength {
+			if i == 0 {
+				b.WriteString(string([]rune(word)[:max(maxLength, 0)]))
+			}
+			break
+		}
+
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(word)
+
+		length += n
+	}
+
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+func isLineBreak(r rune) bool {
+	return r == '\n' || r == '\r'
+}
+
+// parseSpace reads the run of whitespace of the same kind as symbol.
+func (p *Parser) parseSpace(symbol rune, start int) Token {
+
+	l := p.lexer
+
+	tokenType := SPACE
+	{
+		if isLineBreak(symbol) {
+			tokenType = NEWLINE
+		}
+	}
+
+	for {
+		r, ok := l.Peek()
+		if !ok || !unicode.IsSpace(r) || isLineBreak(r) != (tokenType == NEWLINE) {
+			break
+		}
+		l.NextPos()
+	}
+
+	return Token{Type: tokenType, Value: string(l.input[start:l.pos]), Start: start, End: l.pos}
+}
+
+// applySpace drops or folds the SPACE and NEWLINE tokens of a coalesced
+// token stream according to mode.
+func applySpace(tokens []Token, mode SpaceMode) []Token {
+
+	switch mode {
+	case DropSpace:
+		return Filter(tokens, func(token Token) bool {
+			return token.Type != SPACE && token.Type != NEWLINE
+		})
+	case TriviaSpace:
+	default:
+		return tokens
+	}
+
+	var output []Token
+
+	var leading strings.Builder
+
+	trailing := false
+
+	for _, token := range tokens {
+
+		switch token.Type {
+		case SPACE:
+			if trailing {
+				output[len(output)-1].Trailing += token.Value
+			} else {
+				leading.WriteString(token.Value)
+			}
+			continue
+		case NEWLINE:
+			if trailing {
+				i := strings.IndexByte(token.Value, '\n') + 1
+				if i == 0 {
+					i = 1
+				}
+				output[len(output)-1].Trailing += token.Value[:i]
+				leading.WriteString(token.Value[i:])
+				trailing = false
+			} else {
+				leading.WriteString(token.Value)
+			}
+			continue
+		}
+
+		token.Leading = leading.String()
+		leading.Reset()
+
+		output = append(output, token)
+		trailing = token.Type != EOF
+	}
+
+	return output
+}
@@ -0,0 +1,248 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+var Filters = map[string]func(string) string{
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"trim":   strings.TrimSpace,
+	"snake":  SnakeCase,
+	"camel":  CamelCase,
+	"pascal": PascalCase,
+	"kebab":  KebabCase,
+}
+
+// Placeholder is a single {...} occurrence in a template:
+//
+//	{key}           required
+//	{key?}          optional, renders as an empty string when missing
+//	{key=default}   optional, renders default when missing
+//	{key|f1|f2}     value is passed through Filters f1 then f2
+//	{key:int}       value must be an int (string, int, float or bool)
+type Placeholder struct {
+	Key        string
+	Type       string
+	Default    string
+	HasDefault bool
+	Optional   bool
+	Filters    []string
+	Start      int
+	End        int
+}
+
+func ParsePlaceholderToken(token Token) Placeholder {
+
+	ph := Placeholder{Start: token.Start, End: token.End}
+
+	parts := strings.Split(placeholderKey(token), "|")
+
+	for _, filter := range parts[1:] {
+		if filter = strings.TrimSpace(filter); filter != "" {
+			ph.Filters = append(ph.Filters, filter)
+		}
+	}
+
+	key := parts[0]
+
+	if i := strings.IndexByte(key, '='); i >= 0 {
+		ph.Default = key[i+1:]
+		ph.HasDefault = true
+		ph.Optional = true
+		key = key[:i]
+	}
+
+	key = strings.TrimSpace(key)
+
+	if strings.HasSuffix(key, "?") {
+		ph.Optional = true
+		key = strings.TrimSpace(key[:len(key)-1])
+	}
+
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		ph.Type = strings.TrimSpace(key[i+1:])
+		key = strings.TrimSpace(key[:i])
+	}
+
+	ph.Key = key
+
+	return ph
+}
+
+// Template comments {# ... #} never reach the output of Execute.
+type Template struct {
+	input        []rune
+	placeholders []Placeholder
+	comments     []Token
+}
+
+func NewTemplate(input string) *Template {
+
+	t := &Template{input: []rune(input)}
+
+	for _, token := range NewParser(input).ParsePlaceholders() {
+		if token.Type == COMMENT {
+			t.comments = append(t.comments, token)
+			continue
+		}
+		t.placeholders = append(t.placeholders, ParsePlaceholderToken(token))
+	}
+
+	return t
+}
+
+func (t *Template) Comments() []Token {
+	return t.comments
+}
+
+func (t *Template) String() string {
+	return string(t.input)
+}
+
+func (t *Template) Placeholders() []Placeholder {
+	return t.placeholders
+}
+
+type Position struct {
+	Start int
+	End   int
+}
+
+type Variable struct {
+	Key        string
+	Type       string
+	Count      int
+	Positions  []Position
+	Default    string
+	HasDefault bool
+	Filters    []string
+	Optional   bool
+}
+
+// Variables returns one entry per unique key in order of first appearance.
+// A variable is optional only if every occurrence of it is optional.
+func (t *Template) Variables() []Variable {
+
+	var variables []Variable
+
+	index := make(map[string]int)
+
+	for _, ph := range t.placeholders {
+
+		i, ok := index[ph.Key]
+		if !ok {
+			i = len(variables)
+			index[ph.Key] = i
+			variables = append(variables, Variable{Key: ph.Key, Optional: true})
+		}
+
+		v := &variables[i]
+
+		v.Count++
+		v.Positions = append(v.Positions, Position{Start: ph.Start, End: ph.End})
+		v.Optional = v.Optional && ph.Optional
+
+		if ph.Type != "" && v.Type == "" {
+			v.Type = ph.Type
+		}
+
+		if ph.HasDefault && !v.HasDefault {
+			v.Default = ph.Default
+			v.HasDefault = true
+		}
+
+		for _, filter := range ph.Filters {
+			if !contains(v.Filters, filter) {
+				v.Filters = append(v.Filters, filter)
+			}
+		}
+	}
+
+	return variables
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Template) Execute(lookup func(key string) (string, bool)) (string, error) {
+
+	var prevEnd int
+
+	buff := make([]rune, 0, len(t.input))
+
+	comments := t.comments
+
+	for _, ph := range t.placeholders {
+
+		for len(comments) > 0 && comments[0].Start < ph.Start {
+			buff = append(buff, t.input[prevEnd:comments[0].Start]...)
+			prevEnd = comments[0].End
+			comments = comments[1:]
+		}
+
+		value, ok := lookup(ph.Key)
+		{
+			if !ok {
+				switch {
+				case ph.HasDefault:
+					value = ph.Default
+				case ph.Optional:
+					value = ""
+				default:
+					return "", fmt.Errorf("parser: missing value for %q at [%d:%d]", ph.Key, ph.Start, ph.End)
+				}
+			}
+		}
+
+		for _, name := range ph.Filters {
+			filter, ok := Filters[name]
+			if !ok {
+				return "", fmt.Errorf("parser: unknown filter %q at [%d:%d]", name, ph.Start, ph.End)
+			}
+			value = filter(value)
+		}
+
+		buff = append(buff, t.input[prevEnd:ph.Start]...)
+		buff = append(buff, []rune(value)...)
+
+		prevEnd = ph.End
+	}
+
+	for _, comment := range comments {
+		buff = append(buff, t.input[prevEnd:comment.Start]...)
+		prevEnd = comment.End
+	}
+
+	buff = append(buff, t.input[prevEnd:]...)
+
+	return string(buff), nil
+}
+
+func Render[T Replacer](t *Template, replacer T) (string, error) {
+
+	var lookup func(string) (string, bool)
+	{
+		switch v := any(replacer).(type) {
+		case map[string]string:
+			lookup = func(key string) (string, bool) {
+				value, ok := v[key]
+				return value, ok
+			}
+		case func(string) string:
+			lookup = func(key string) (string, bool) {
+				value := v(key)
+				return value, value != ""
+			}
+		}
+	}
+
+	return t.Execute(lookup)
+}
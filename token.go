@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"unicode"
 )
 
@@ -37,14 +38,33 @@ const (
 	PERCENT                      // %
 	CARET                        // ^
 	AMPERSAND                    // &
-	BAR                          // |
+	BAR                          // ||
 	UNDERSCORE                   // _
 	AT                           // @
 	EXCLAMATION                  // !
 	QUESTION                     // ?
 	PERIOD                       // .
+	LBRACKET                     // Left bracket [
+	RBRACKET                     // Right bracket ]
+	LT                           // <
+	GT                           // >
+	DOLLAR                       // $
+	HASH                         // #
+	TILDE                        // ~
+	BACKTICK                     // `
+	EQ                           // ==
+	NEQ                          // !=
+	LTE                          // <=
+	GTE                          // >=
+	AND                          // &&
+	ARROW                        // ->
+	DCOLON                       // ::
+	NEWLINE                      // Line break
 )
 
+// OR is the former name of BAR.
+const OR = BAR
+
 var TokenStrings = [...]string{
 	EOF:         "EOF",         // End of file
 	SPACE:       "SPACE",       // Space
@@ -74,12 +94,28 @@ var TokenStrings = [...]string{
 	PERCENT:     "PERCENT",     // %
 	CARET:       "CARET",       // ^
 	AMPERSAND:   "AMPERSAND",   // &
-	BAR:         "BAR",         // |
+	BAR:         "BAR",         // ||
 	UNDERSCORE:  "UNDERSCORE",  // _
 	AT:          "AT",          // @
 	EXCLAMATION: "EXCLAMATION", // !
 	QUESTION:    "QUESTION",    // ?
 	PERIOD:      "PERIOD",      // .
+	LBRACKET:    "LBRACKET",    // Left bracket [
+	RBRACKET:    "RBRACKET",    // Right bracket ]
+	LT:          "LT",          // <
+	GT:          "GT",          // >
+	DOLLAR:      "DOLLAR",      // $
+	HASH:        "HASH",        // #
+	TILDE:       "TILDE",       // ~
+	BACKTICK:    "BACKTICK",    // `
+	EQ:          "EQ",          // ==
+	NEQ:         "NEQ",         // !=
+	LTE:         "LTE",         // <=
+	GTE:         "GTE",         // >=
+	AND:         "AND",         // &&
+	ARROW:       "ARROW",       // ->
+	DCOLON:      "DCOLON",      // ::
+	NEWLINE:     "NEWLINE",     // Line break
 }
 
 var tokenTypeNames = map[string]TokenType{
@@ -111,24 +147,99 @@ var tokenTypeNames = map[string]TokenType{
 	"PERCENT":     PERCENT,     // %
 	"CARET":       CARET,       // ^
 	"AMPERSAND":   AMPERSAND,   // &
-	"BAR":         BAR,         // |
+	"BAR":         BAR,         // ||
+	"OR":          OR,          // || (alias of BAR)
 	"UNDERSCORE":  UNDERSCORE,  // _
 	"AT":          AT,          // @
 	"EXCLAMATION": EXCLAMATION, // !
 	"QUESTION":    QUESTION,    // ?
 	"PERIOD":      PERIOD,      // .
+	"LBRACKET":    LBRACKET,    // Left bracket [
+	"RBRACKET":    RBRACKET,    // Right bracket ]
+	"LT":          LT,          // <
+	"GT":          GT,          // >
+	"DOLLAR":      DOLLAR,      // $
+	"HASH":        HASH,        // #
+	"TILDE":       TILDE,       // ~
+	"BACKTICK":    BACKTICK,    // `
+	"EQ":          EQ,          // ==
+	"NEQ":         NEQ,         // !=
+	"LTE":         LTE,         // <=
+	"GTE":         GTE,         // >=
+	"AND":         AND,         // &&
+	"ARROW":       ARROW,       // ->
+	"DCOLON":      DCOLON,      // ::
+	"NEWLINE":     NEWLINE,     // Line break
+}
+
+var tokenTypeRegistry struct {
+	sync.RWMutex
+	names []string // Names of registered types, starting after TokenStrings
+}
+
+// RegisterTokenType returns a new TokenType printed as name, e.g. for the
+// result of a Rule. Registering a name that is already known returns its type.
+func RegisterTokenType(name string) TokenType {
+
+	tokenTypeRegistry.Lock()
+	defer tokenTypeRegistry.Unlock()
+
+	if tokenType, ok := tokenTypeNames[name]; ok {
+		return tokenType
+	}
+
+	tokenType := TokenType(len(TokenStrings) + len(tokenTypeRegistry.names))
+
+	tokenTypeRegistry.names = append(tokenTypeRegistry.names, name)
+	tokenTypeNames[name] = tokenType
+
+	return tokenType
+}
+
+func ParseTokenType(name string) (TokenType, bool) {
+
+	tokenTypeRegistry.RLock()
+	defer tokenTypeRegistry.RUnlock()
+
+	tokenType, ok := tokenTypeNames[name]
+
+	return tokenType, ok
+}
+
+// String returns the name of t, or TokenType(n) when t is unknown.
+func (t TokenType) String() string {
+
+	if t >= 0 && int(t) < len(TokenStrings) {
+		return TokenStrings[t]
+	}
+
+	tokenTypeRegistry.RLock()
+	defer tokenTypeRegistry.RUnlock()
+
+	if i := int(t) - len(TokenStrings); i >= 0 && i < len(tokenTypeRegistry.names) {
+		return tokenTypeRegistry.names[i]
+	}
+
+	return fmt.Sprintf("TokenType(%d)", int(t))
 }
 
 type Token struct {
 	Type   TokenType
 	Value  string
+	Raw    string     // Source text when it differs from Value, e.g. a quoted STRING
+	Kind   NumberKind // Set on NUMBER tokens
 	Start  int
 	End    int
 	IsLast bool
+
+	// Whitespace around the token with TriviaSpace: Trailing runs to the end
+	// of the line, Leading holds the rest since the previous token.
+	Leading  string
+	Trailing string
 }
 
 func (t Token) String() string {
-	return fmt.Sprintf("%s: %s [%d:%d]\n", TokenStrings[t.Type], t.Value, t.Start, t.End)
+	return fmt.Sprintf("%s: %s [%d:%d]\n", t.Type, t.Value, t.Start, t.End)
 }
 
 func (t Token) Trim() string {
@@ -164,13 +275,19 @@ func (t Token) IsLetter() bool {
 }
 
 func (t Token) Split(splitter func(rune) bool) []Token {
+	return t.SplitAt(func(runes []rune, i int) bool {
+		return splitter(runes[i])
+	})
+}
+
+func (t Token) SplitAt(boundary func(runes []rune, i int) bool) []Token {
 
 	var tokens []Token
 	start := 0
 	runes := []rune(t.Value)
 
 	for i := 0; i < len(runes); i++ {
-		if i > 0 && splitter(runes[i]) {
+		if i > 0 && boundary(runes, i) {
 			tokens = append(tokens, Token{
 				Type:  t.Type,
 				Value: string(runes[start:i]),
@@ -217,6 +334,19 @@ func (t Token) SplitPunct() []Token {
 	return t.Split(unicode.IsPunct)
 }
 
+// SplitWords splits the token value into words with the same rules as Words.
+// Start and End are rune offsets into the token value.
+func (t Token) SplitWords() []Token {
+
+	words := Words(t.Value)
+
+	for i := range words {
+		words[i].Type = t.Type
+	}
+
+	return words
+}
+
 func (t Token) Join(joiner func() []Token, transform func(string) string) string {
 	var result []byte
 	for _, v := range joiner() {
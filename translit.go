@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TranslitTable maps lower case runes to their ASCII spelling. Upper case
+// input is looked up by its lower case form and re-cased on output.
+type TranslitTable map[rune]string
+
+var (
+	CyrillicTranslit = TranslitTable{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+		'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+		'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+		'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+		'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+		// Uzbek
+		'ў': "o", 'қ': "q", 'ғ': "g", 'ҳ': "h",
+		// Ukrainian
+		'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+	}
+
+	GreekTranslit = TranslitTable{
+		'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+		'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+		'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+		'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+		'ά': "a", 'έ': "e", 'ή': "i", 'ί': "i", 'ό': "o", 'ύ': "y", 'ώ': "o",
+		'ϊ': "i", 'ϋ': "y", 'ΐ': "i", 'ΰ': "y",
+	}
+
+	LatinTranslit = TranslitTable{
+		'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+		'ă': "a", 'ą': "a", 'æ': "ae", 'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c",
+		'č': "c", 'ď': "d", 'đ': "d", 'ð': "d", 'è': "e", 'é': "e", 'ê': "e",
+		'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e", 'ĝ': "g",
+		'ğ': "g", 'ġ': "g", 'ģ': "g", 'ĥ': "h", 'ħ': "h", 'ì': "i", 'í': "i",
+		'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+		'ĳ': "ij", 'ĵ': "j", 'ķ': "k", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l",
+		'ł': "l", 'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n", 'ò': "o", 'ó': "o",
+		'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+		'œ': "oe", 'ŕ': "r", 'ŗ': "r", 'ř': "r", 'ś': "s", 'ŝ': "s", 'ş': "s",
+		'š': "s", 'ș': "s", 'ß': "ss", 'ţ': "t", 'ť': "t", 'ŧ': "t", 'ț': "t",
+		'þ': "th", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u",
+		'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u", 'ŵ': "w", 'ý': "y", 'ÿ': "y",
+		'ŷ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+	}
+
+	DefaultTranslit = MergeTranslit(CyrillicTranslit, GreekTranslit, LatinTranslit)
+)
+
+// MergeTranslit returns a new table with the entries of all tables; later
+// tables win on conflicts.
+func MergeTranslit(tables ...TranslitTable) TranslitTable {
+
+	merged := make(TranslitTable)
+
+	for _, table := range tables {
+		for r, s := range table {
+			merged[r] = s
+		}
+	}
+
+	return merged
+}
+
+// Transliterate replaces every rune found in table. An upper case rune gives
+// a capitalised spelling, or an all-caps one when it sits next to another
+// upper case letter: Щука -> Shchuka, ЩУКА -> SHCHUKA.
+func Transliterate(s string, table TranslitTable) string {
+
+	runes := []rune(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i, r := range runes {
+
+		lower := unicode.ToLower(r)
+
+		repl, ok := table[lower]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		if lower == r || repl == "" {
+			b.WriteString(repl)
+			continue
+		}
+
+		allCaps := i > 0 && unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsUpper(runes[i+1])
+		if allCaps {
+			b.WriteString(strings.ToUpper(repl))
+			continue
+		}
+
+		first, size := utf8.DecodeRuneInString(repl)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(repl[size:])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,24 @@
+package parser
+
+import "unsafe"
+
+// StringToBytes returns the bytes of s without copying. The result aliases the
+// memory of s, which may be read-only (string literals): it must never be
+// written to or appended to in place, and must not outlive the caller's use of
+// s. An empty s yields a nil slice.
+func StringToBytes(s string) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// BytesToString returns b as a string without copying. The result aliases b:
+// the caller must not modify b afterwards, or the string changes under every
+// holder of it. An empty or nil b yields "".
+func BytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ProblemKind int
+
+const (
+	MissingKey   ProblemKind = iota // Required key not provided
+	UnusedKey                       // Provided key not used by the template
+	TypeMismatch                    // Value does not match {key:type}
+	InvalidData                     // Data is not a map, struct or KeyFunc
+)
+
+var problemKindNames = [...]string{
+	MissingKey:   "missing key",
+	UnusedKey:    "unused key",
+	TypeMismatch: "type mismatch",
+	InvalidData:  "invalid data",
+}
+
+// String returns the name of k, or ProblemKind(n) when k is unknown.
+func (k ProblemKind) String() string {
+	if k >= 0 && int(k) < len(problemKindNames) {
+		return problemKindNames[k]
+	}
+	return fmt.Sprintf("ProblemKind(%d)", int(k))
+}
+
+type Problem struct {
+	Kind      ProblemKind
+	Key       string
+	Message   string
+	Positions []Position
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Kind, p.Key, p.Message)
+}
+
+// KeyFunc describes function data whose key set is not discoverable.
+type KeyFunc struct {
+	Keys []string
+	Func func(string) string
+}
+
+// Validate compares the variables used by t with data, which may be a map with
+// string keys, a struct (or pointer to one) or a KeyFunc. Struct fields are
+// keyed by their `parser:"name"` tag, or by field name when untagged.
+func Validate(t *Template, data any) []Problem {
+
+	values, ok := dataValues(data)
+	if !ok {
+		return []Problem{{Kind: InvalidData, Message: fmt.Sprintf("unsupported data type %T", data)}}
+	}
+
+	var problems []Problem
+
+	used := make(map[string]bool)
+
+	for _, v := range t.Variables() {
+
+		used[v.Key] = true
+
+		value, ok := values[v.Key]
+		if !ok {
+			if !v.Optional {
+				problems = append(problems, Problem{
+					Kind:      MissingKey,
+					Key:       v.Key,
+					Message:   "required by template",
+					Positions: v.Positions,
+				})
+			}
+			continue
+		}
+
+		if v.Type == "" {
+			continue
+		}
+
+		if err := checkType(v.Type, value()); err != nil {
+			problems = append(problems, Problem{
+				Kind:      TypeMismatch,
+				Key:       v.Key,
+				Message:   err.Error(),
+				Positions: v.Positions,
+			})
+		}
+	}
+
+	for _, key := range sortedKeys(values) {
+		if !used[key] {
+			problems = append(problems, Problem{
+				Kind:    UnusedKey,
+				Key:     key,
+				Message: "not used by template",
+			})
+		}
+	}
+
+	return problems
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func dataValues(data any) (map[string]func() any, bool) {
+
+	values := make(map[string]func() any)
+
+	if kf, ok := data.(KeyFunc); ok {
+		for _, key := range kf.Keys {
+			values[key] = func() any { return kf.Func(key) }
+		}
+		return values, true
+	}
+
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			values[iter.Key().String()] = func() any { return value.Interface() }
+		}
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			key := field.Name
+			if tag, ok := field.Tag.Lookup("parser"); ok {
+				if tag == "-" {
+					continue
+				}
+				key = tag
+			}
+
+			value := rv.Field(i)
+			values[key] = func() any { return value.Interface() }
+		}
+	default:
+		return nil, false
+	}
+
+	return values, true
+}
+
+func checkType(typ string, value any) error {
+
+	if s, ok := value.(string); ok {
+		var err error
+		{
+			switch strings.ToLower(typ) {
+			case "int":
+				_, err = strconv.ParseInt(s, 10, 64)
+			case "float":
+				_, err = strconv.ParseFloat(s, 64)
+			case "bool":
+				_, err = strconv.ParseBool(s)
+			case "string":
+			default:
+				return fmt.Errorf("unknown type %q", typ)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%q is not a valid %s", s, typ)
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+
+	var ok bool
+	{
+		switch strings.ToLower(typ) {
+		case "int":
+			ok = rv.CanInt() || rv.CanUint()
+		case "float":
+			ok = rv.CanFloat() || rv.CanInt() || rv.CanUint()
+		case "bool":
+			ok = rv.Kind() == reflect.Bool
+		case "string":
+			ok = rv.Kind() == reflect.String
+		default:
+			return fmt.Errorf("unknown type %q", typ)
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf("%T is not a valid %s", value, typ)
+	}
+
+	return nil
+}
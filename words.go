@@ -0,0 +1,388 @@
+package parser
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+var Initialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true,
+	"QPS": true, "RAM": true, "RHS": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true,
+	"TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true,
+	"XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// Words splits s into the words every case converter works on, the way a Go
+// programmer reads identifiers:
+//
+//	HTTPServer      -> HTTP Server
+//	userID          -> user ID
+//	v2Api           -> v2 Api
+//	ipv4Addr        -> ipv4 Addr
+//	JSONAPIResponse -> JSON API Response
+//	USER2FA         -> USER2FA
+//
+// Anything that is not a letter or a digit separates words. Start and End are
+// rune offsets into s.
+func Words(s string) []Token {
+
+	var words []Token
+
+	w := newWordScanner(s)
+
+	byteOffset, runeOffset := 0, 0
+
+	for {
+		start, end, ok := w.next()
+		if !ok {
+			break
+		}
+
+		runeOffset += utf8.RuneCountInString(s[byteOffset:start])
+		n := utf8.RuneCountInString(s[start:end])
+
+		words = append(words, Token{
+			Type:  IDENT,
+			Value: s[start:end],
+			Start: runeOffset,
+			End:   runeOffset + n,
+		})
+
+		byteOffset, runeOffset = end, runeOffset+n
+	}
+
+	return words
+}
+
+type wordScanner struct {
+	s      string
+	ascii  bool
+	pos    int // byte offset of the next word
+	runEnd int // byte offset where the current run of letters and digits ends
+	mixed  bool
+
+	// An all-caps word made of Initialisms is segmented once; cuts holds the
+	// length of the initialism starting at each byte of s[capsStart:capsEnd].
+	capsStart int
+	capsEnd   int
+	cuts      initialismCuts
+}
+
+func newWordScanner(s string) wordScanner {
+
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+
+	return wordScanner{s: s, ascii: ascii}
+}
+
+func (w *wordScanner) decode(i int) (rune, int) {
+	if i >= len(w.s) {
+		return utf8.RuneError, 0
+	}
+	if w.ascii {
+		return rune(w.s[i]), 1
+	}
+	return utf8.DecodeRuneInString(w.s[i:])
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// next returns the byte range of the next word in s.
+func (w *wordScanner) next() (start, end int, ok bool) {
+
+	if w.pos >= w.runEnd && !w.nextRun() {
+		return 0, 0, false
+	}
+
+	start = w.pos
+
+	if start > w.capsStart && start < w.capsEnd {
+		w.pos = start + w.cuts.get(start-w.capsStart)
+		return start, w.pos, true
+	}
+
+	prev, size := w.decode(start)
+	end = start + size
+
+	for end < w.runEnd {
+		cur, size := w.decode(end)
+		if w.isBoundary(prev, cur, end, size) {
+			break
+		}
+		prev = cur
+		end += size
+	}
+
+	if w.segment(start, end) {
+		end = start + w.cuts.get(0)
+	}
+
+	w.pos = end
+
+	return start, end, true
+}
+
+func (w *wordScanner) nextRun() bool {
+
+	i := w.pos
+
+	for i < len(w.s) {
+		r, size := w.decode(i)
+		if isWordRune(r) {
+			break
+		}
+		i += size
+	}
+
+	w.pos = i
+	w.mixed = false
+
+	for i < len(w.s) {
+		r, size := w.decode(i)
+		if !isWordRune(r) {
+			break
+		}
+		if unicode.IsLower(r) {
+			w.mixed = true
+		}
+		i += size
+	}
+
+	w.runEnd = i
+
+	return w.pos < w.runEnd
+}
+
+// isBoundary reports whether a new word starts with cur at byte offset at. In
+// an all-caps run a digit only ends a word when Initialisms or a single letter
+// follow it, so IPV4 and USER2FA stay whole while X11ID and V2V2 split.
+//
+// Capitals followed by lower case normally give up their last capital to the
+// next word (HTTPServer), unless only the whole run is made of Initialisms:
+// UUIDv4 -> UUID v4. A lone s after such a run is a plural, as in golint:
+// userIDs -> user IDs, APIs -> APIs.
+func (w *wordScanner) isBoundary(prev, cur rune, at, size int) bool {
+
+	if unicode.IsLower(cur) {
+		if !unicode.IsUpper(prev) || w.isPlural(cur, at+size) {
+			return false
+		}
+		return w.keepsCapitals(w.upperStart(at), at)
+	}
+
+	if !unicode.IsUpper(cur) {
+		return false
+	}
+
+	switch {
+	case unicode.IsUpper(prev):
+		next, nextSize := w.decode(at + size)
+		if at+size >= w.runEnd || !unicode.IsLower(next) {
+			return false
+		}
+		start := w.upperStart(at)
+		if w.keepsCapitals(start, at+size) {
+			return false
+		}
+		return !(w.isPlural(next, at+size+nextSize) && w.splits(w.s[start:at+size]))
+	case unicode.IsDigit(prev):
+		if w.mixed {
+			return true
+		}
+		j, n := at, 0
+		for j < w.runEnd {
+			r, size := w.decode(j)
+			if !unicode.IsUpper(r) {
+				break
+			}
+			j += size
+			n++
+		}
+		return n == 1 || w.splits(w.s[at:j])
+	case unicode.IsLower(prev) && at-utf8.RuneLen(prev) == w.pos:
+		// A lone lower case letter opens a word such as iOS when the
+		// capitals after it run to the end and are not Initialisms.
+		j, n := at, 0
+		for j < w.runEnd {
+			r, size := w.decode(j)
+			if !unicode.IsUpper(r) {
+				break
+			}
+			j += size
+			n++
+		}
+		next, _ := w.decode(j)
+		return n < 2 || (j < w.runEnd && unicode.IsLower(next)) || w.splits(w.s[at:j])
+	}
+
+	return true
+}
+
+// keepsCapitals reports whether the capitals s[start:end] stay one word
+// because they are made of Initialisms while s[start:end) minus the last
+// capital is not.
+func (w *wordScanner) keepsCapitals(start, end int) bool {
+
+	_, last := utf8.DecodeLastRuneInString(w.s[start:end])
+
+	return end-start > last && !w.splits(w.s[start:end-last]) && w.splits(w.s[start:end])
+}
+
+// isPlural reports whether r, ending at byte offset end, is a lone s.
+func (w *wordScanner) isPlural(r rune, end int) bool {
+
+	if r != 's' {
+		return false
+	}
+
+	next, _ := w.decode(end)
+
+	return end >= w.runEnd || !unicode.IsLower(next)
+}
+
+// upperStart returns the byte offset where the capitals ending at at start,
+// without going back past the current word.
+func (w *wordScanner) upperStart(at int) int {
+	for at > w.pos {
+		r, size := utf8.DecodeLastRuneInString(w.s[w.pos:at])
+		if !unicode.IsUpper(r) {
+			break
+		}
+		at -= size
+	}
+	return at
+}
+
+func (w *wordScanner) splits(s string) bool {
+	return splitsIntoInitialisms(s, maxInitialismLen())
+}
+
+// maxInitialismLen returns the length of the longest of the Initialisms,
+// which bounds every lookup while splitting. Keys longer than 64 bytes are
+// never split out of a word.
+func maxInitialismLen() int {
+	n := 0
+	for key := range Initialisms {
+		if len(key) > n && len(key) <= 64 {
+			n = len(key)
+		}
+	}
+	return n
+}
+
+func isAllUpper(word string) bool {
+	for _, r := range word {
+		if !unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// segment splits the all-caps word s[start:end] into Initialisms, e.g.
+// JSONAPI into JSON API, and reports whether it did. The split is computed
+// right to left in one pass, preferring the longest initialism at each cut.
+func (w *wordScanner) segment(start, end int) bool {
+
+	word := w.s[start:end]
+
+	if len(word) < 2 || Initialisms[word] || !isAllUpper(word) {
+		return false
+	}
+
+	maxLen := maxInitialismLen()
+
+	w.cuts.reset(len(word))
+
+	for i := len(word) - 1; i >= 0; i-- {
+		for k := min(maxLen, len(word)-i); k > 0; k-- {
+			if Initialisms[word[i:i+k]] && (i+k == len(word) || w.cuts.get(i+k) > 0) {
+				w.cuts.set(i, k)
+				break
+			}
+		}
+	}
+
+	if w.cuts.get(0) == 0 {
+		return false
+	}
+
+	w.capsStart, w.capsEnd = start, end
+
+	return true
+}
+
+// initialismCuts stores one cut length per byte of a word, on the stack for
+// words of up to 64 bytes.
+type initialismCuts struct {
+	small [64]uint8
+	large []uint8
+	n     int
+}
+
+func (c *initialismCuts) reset(n int) {
+	c.n = n
+	if n > len(c.small) {
+		c.large = make([]uint8, n)
+		return
+	}
+	c.large = nil
+	c.small = [64]uint8{}
+}
+
+func (c *initialismCuts) get(i int) int {
+	if c.large != nil {
+		return int(c.large[i])
+	}
+	return int(c.small[i])
+}
+
+func (c *initialismCuts) set(i, k int) {
+	if c.large != nil {
+		c.large[i] = uint8(k)
+		return
+	}
+	c.small[i] = uint8(k)
+}
+
+// splitsIntoInitialisms reports whether s is a sequence of Initialisms of at
+// most maxLen bytes each. It walks s right to left keeping, in window, which
+// of the next 64 suffixes split.
+func splitsIntoInitialisms(s string, maxLen int) bool {
+
+	window := uint64(1) // bit k-1: s[i+k:] splits
+
+	ok := true
+
+	for i := len(s) - 1; i >= 0; i-- {
+		ok = false
+		for k := 1; k <= min(maxLen, len(s)-i); k++ {
+			if window&(1<<(k-1)) != 0 && Initialisms[s[i:i+k]] {
+				ok = true
+				break
+			}
+		}
+		window = window<<1 | b2u(ok)
+	}
+
+	return ok
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}